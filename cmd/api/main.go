@@ -8,12 +8,17 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	// Inclui a base de fusos horários no binário, ausente na imagem alpine
+	_ "time/tzdata"
 
 	"api-itau/config"
 	"api-itau/handlers"
+	"api-itau/internal/idempotency"
 	"api-itau/internal/middleware"
 	"api-itau/internal/services"
 	"api-itau/pkg/logger"
+	"api-itau/pkg/utils"
+	"api-itau/pkg/validator"
 
 	scalar "github.com/MarceloPetrucio/go-scalar-api-reference"
 )
@@ -31,10 +36,26 @@ func main() {
 	// Cria os serviços
 	statsService := services.NewStatisticsService(cfg, log)
 	transactionService := services.NewTransactionService(statsService, log)
+	statsBroadcaster := services.NewStatisticsBroadcaster(statsService, cfg.Stats.StreamInterval, log)
+	statsBroadcaster.Start()
 
 	// Cria os handlers
-	statsHandler := handlers.NewStatisticsHandler(statsService, log)
-	transactionHandler := handlers.NewTransactionHandler(transactionService, log)
+	responder := handlers.NewResponder(handlers.ResponseProfile(cfg.Response.Profile), handlers.ErrorFormat(cfg.Response.ErrorFormat))
+	statsHandler := handlers.NewStatisticsHandler(statsService, log, handlers.WithStatisticsResponder(responder))
+	idempotencyStore := idempotency.NewStore(cfg.Idempotency.TTL, cfg.Idempotency.MaxKeys, utils.GetTimeProvider())
+	transactionHandler := handlers.NewTransactionHandler(transactionService, log,
+		handlers.WithIdempotency(idempotencyStore),
+		handlers.WithBatchLimit(cfg.Ingest.BatchMaxItems),
+		handlers.WithStreamLimits(cfg.Ingest.StreamMaxLineBytes, cfg.Ingest.StreamMaxLines),
+		handlers.WithCSVDelimiter(cfg.Ingest.CSVDelimiter),
+		handlers.WithValidator(validator.NewTransactionValidator(cfg.Validation.MaxValue, cfg.Validation.MaxAge)),
+		handlers.WithResponder(responder),
+	)
+	statsStreamHandler := handlers.NewStatisticsStreamHandler(statsBroadcaster, log)
+	wsHandler := handlers.NewWebSocketHandler(statsBroadcaster, log, handlers.WithWebSocketResponder(responder))
+
+	// Publica as transações aceitas no feed WebSocket
+	transactionService.OnTransaction(wsHandler.PublishTransaction)
 
 	// Cria o router
 	mux := http.NewServeMux()
@@ -47,7 +68,20 @@ func main() {
 
 	mux.Handle("POST /transacao", transactionHandler)
 	mux.Handle("DELETE /transacao", transactionHandler)
+	mux.Handle("GET /transacao/{id}", transactionHandler)
+	mux.HandleFunc("POST /transacao/lote", transactionHandler.HandleBatch)
+	mux.HandleFunc("POST /transacao/stream", transactionHandler.HandleStream)
+	mux.HandleFunc("POST /transacao/importar", transactionHandler.HandleImport)
+	mux.HandleFunc("GET /transacao/exportar", transactionHandler.HandleExport)
 	mux.Handle("GET /estatistica", statsHandler)
+	mux.HandleFunc("GET /estatistica/serie", statsHandler.HandleSeries)
+	mux.HandleFunc("GET /estatistica/top", statsHandler.HandleTop)
+	mux.HandleFunc("GET /estatistica/histograma", statsHandler.HandleHistogram)
+	mux.HandleFunc("GET /estatistica/taxa", statsHandler.HandleThroughput)
+	mux.HandleFunc("GET /estatistica/comparativo", statsHandler.HandleComparison)
+	mux.HandleFunc("GET /estatistica/periodo", statsHandler.HandlePeriods)
+	mux.Handle("GET /estatistica/stream", statsStreamHandler)
+	mux.Handle("GET /ws", wsHandler)
 
 	// Adiciona a rota para a documentação
 	mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
@@ -70,7 +104,7 @@ func main() {
 	// Aplica os middlewares
 	handler := middleware.RequestIDMiddleware(log)(
 		middleware.LoggingMiddleware(log)(
-			middleware.RecoveryMiddleware(log)(mux),
+			middleware.RecoveryMiddleware(log, responder.InternalError)(mux),
 		),
 	)
 
@@ -83,6 +117,11 @@ func main() {
 		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	// Encerra os streams SSE e as conexões WebSocket no início do shutdown,
+	// para que o servidor não fique aguardando conexões de longa duração
+	server.RegisterOnShutdown(statsBroadcaster.Close)
+	server.RegisterOnShutdown(wsHandler.Close)
+
 	// Canal para erros do servidor
 	serverErrors := make(chan error, 1)
 
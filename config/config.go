@@ -4,13 +4,24 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
+
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/histogram"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Stats    StatsConfig
-	LogLevel string
+	Server      ServerConfig
+	Stats       StatsConfig
+	Idempotency IdempotencyConfig
+	Ingest      IngestConfig
+	Validation  ValidationConfig
+	Response    ResponseConfig
+	Currency    CurrencyConfig
+	LogLevel    string
 }
 
 type ServerConfig struct {
@@ -21,16 +32,86 @@ type ServerConfig struct {
 }
 
 type StatsConfig struct {
-	WindowSeconds int
+	WindowSeconds    int
+	RetentionSeconds int
+	StreamInterval   time.Duration
+	Scale            int
+	Rounding         string
+	// LabelMaxNames limita os rótulos distintos agrupados nas estatísticas
+	LabelMaxNames  int
+	LabelMaxValues int
+	TopMax         int
+	// MaxTransactions limita as transações mantidas individualmente para
+	// consulta por id, exportação e estatísticas filtradas
+	MaxTransactions int
+	// HistogramBounds são os limites superiores das faixas do histograma
+	HistogramBounds []decimal.Decimal
+	// PeriodTimezones são os fusos horários das agregações por período de
+	// calendário; o primeiro é o padrão das consultas
+	PeriodTimezones []string
+	PeriodRetention time.Duration
+}
+
+type IdempotencyConfig struct {
+	TTL     time.Duration
+	MaxKeys int
+}
+
+type IngestConfig struct {
+	BatchMaxItems      int
+	StreamMaxLineBytes int
+	StreamMaxLines     int
+	CSVDelimiter       rune
+}
+
+type ValidationConfig struct {
+	MaxValue decimal.Decimal
+	MaxAge   time.Duration
+}
+
+type ResponseConfig struct {
+	Profile     string
+	ErrorFormat string
+}
+
+// CurrencyConfig define a moeda base e a tabela de cotações usadas no total
+// consolidado das estatísticas. Rates vazio desativa a consolidação.
+type CurrencyConfig struct {
+	Base  string
+	Rates map[string]decimal.Decimal
 }
 
+// defaultMaxValue é o maior valor de transação aceito por padrão
+var defaultMaxValue = decimal.NewFromInt(1000000000)
+
 const (
 	defaultPort               = "8080"
 	defaultStatsWindowSeconds = 60
+	defaultStatsRetention     = 600
+	defaultStatsStreamPeriod  = 1 * time.Second
 	defaultReadTimeout        = 5 * time.Second
 	defaultWriteTimeout       = 10 * time.Second
 	defaultIdleTimeout        = 15 * time.Second
 	defaultLogLevel           = "info"
+	defaultStatsScale         = 2
+	defaultStatsRounding      = "half_even"
+	defaultLabelMaxNames      = 20
+	defaultLabelMaxValues     = 100
+	defaultStatsTopMax        = 100
+	defaultMaxTransactions    = 1000000
+	defaultStatsHistogram     = "exponencial:10,10,8"
+	defaultPeriodTimezones    = "America/Sao_Paulo"
+	defaultPeriodRetention    = 7 * 24 * time.Hour
+	defaultIdempotencyTTL     = 24 * time.Hour
+	defaultIdempotencyMaxKeys = 100000
+	defaultBatchMaxItems      = 1000
+	defaultStreamMaxLineBytes = 64 << 10
+	defaultStreamMaxLines     = 1000000
+	defaultCSVDelimiter       = ','
+	defaultMaxAge             = 5 * 365 * 24 * time.Hour
+	defaultErrorFormat        = "json"
+	defaultResponseProfile    = "enveloped"
+	defaultCurrencyBase       = currency.Default
 )
 
 func Load() (*Config, error) {
@@ -42,11 +123,54 @@ func Load() (*Config, error) {
 			IdleTimeout:  getEnvDuration("IDLE_TIMEOUT", defaultIdleTimeout),
 		},
 		Stats: StatsConfig{
-			WindowSeconds: getEnvInt("STATS_WINDOW_SECONDS", defaultStatsWindowSeconds),
+			WindowSeconds:    getEnvInt("STATS_WINDOW_SECONDS", defaultStatsWindowSeconds),
+			RetentionSeconds: getEnvInt("STATS_RETENTION_SECONDS", defaultStatsRetention),
+			StreamInterval:   getEnvDuration("STATS_STREAM_INTERVAL", defaultStatsStreamPeriod),
+			Scale:            getEnvInt("STATS_SCALE", defaultStatsScale),
+			Rounding:         getEnvString("STATS_ROUNDING", defaultStatsRounding),
+			LabelMaxNames:    getEnvInt("STATS_LABEL_MAX_NAMES", defaultLabelMaxNames),
+			LabelMaxValues:   getEnvInt("STATS_LABEL_MAX_VALUES", defaultLabelMaxValues),
+			TopMax:           getEnvInt("STATS_TOP_MAX", defaultStatsTopMax),
+			MaxTransactions:  getEnvInt("STATS_MAX_TRANSACTIONS", defaultMaxTransactions),
+			PeriodTimezones:  getEnvList("STATS_PERIOD_TIMEZONES", defaultPeriodTimezones),
+			PeriodRetention:  getEnvDuration("STATS_PERIOD_RETENTION", defaultPeriodRetention),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:     getEnvDuration("IDEMPOTENCY_TTL", defaultIdempotencyTTL),
+			MaxKeys: getEnvInt("IDEMPOTENCY_MAX_KEYS", defaultIdempotencyMaxKeys),
+		},
+		Ingest: IngestConfig{
+			BatchMaxItems:      getEnvInt("BATCH_MAX_ITEMS", defaultBatchMaxItems),
+			StreamMaxLineBytes: getEnvInt("STREAM_MAX_LINE_BYTES", defaultStreamMaxLineBytes),
+			StreamMaxLines:     getEnvInt("STREAM_MAX_LINES", defaultStreamMaxLines),
+			CSVDelimiter:       getEnvRune("CSV_DELIMITER", defaultCSVDelimiter),
+		},
+		Validation: ValidationConfig{
+			MaxValue: getEnvDecimal("TRANSACTION_MAX_VALUE", defaultMaxValue),
+			MaxAge:   getEnvDuration("TRANSACTION_MAX_AGE", defaultMaxAge),
+		},
+		Response: ResponseConfig{
+			Profile:     getEnvString("RESPONSE_PROFILE", defaultResponseProfile),
+			ErrorFormat: getEnvString("ERROR_FORMAT", defaultErrorFormat),
+		},
+		Currency: CurrencyConfig{
+			Base: getEnvString("CURRENCY_BASE", defaultCurrencyBase),
 		},
 		LogLevel: getEnvString("LOG_LEVEL", defaultLogLevel),
 	}
 
+	rates, err := currency.ParseRates(getEnvString("CURRENCY_RATES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("erro na validação das configurações: CURRENCY_RATES: %w", err)
+	}
+	cfg.Currency.Rates = rates
+
+	bounds, err := histogram.ParseBounds(getEnvString("STATS_HISTOGRAM", defaultStatsHistogram))
+	if err != nil {
+		return nil, fmt.Errorf("erro na validação das configurações: STATS_HISTOGRAM: %w", err)
+	}
+	cfg.Stats.HistogramBounds = bounds
+
 	// Validação das configurações
 	if err := cfg.validate(); err != nil {
 		return nil, fmt.Errorf("erro na validação das configurações: %w", err)
@@ -60,6 +184,96 @@ func (c *Config) validate() error {
 		return fmt.Errorf("STATS_WINDOW_SECONDS deve ser maior que zero")
 	}
 
+	if c.Stats.RetentionSeconds < c.Stats.WindowSeconds {
+		return fmt.Errorf("STATS_RETENTION_SECONDS deve ser maior ou igual a STATS_WINDOW_SECONDS")
+	}
+
+	if c.Stats.StreamInterval <= 0 {
+		return fmt.Errorf("STATS_STREAM_INTERVAL deve ser maior que zero")
+	}
+
+	if c.Stats.Scale < 0 || c.Stats.Scale > 18 {
+		return fmt.Errorf("STATS_SCALE deve estar entre 0 e 18")
+	}
+
+	if _, err := decimal.ParseRoundingMode(c.Stats.Rounding); err != nil {
+		return fmt.Errorf("STATS_ROUNDING deve ser half_even ou half_up")
+	}
+
+	if c.Stats.LabelMaxNames <= 0 {
+		return fmt.Errorf("STATS_LABEL_MAX_NAMES deve ser maior que zero")
+	}
+
+	if c.Stats.LabelMaxValues <= 0 {
+		return fmt.Errorf("STATS_LABEL_MAX_VALUES deve ser maior que zero")
+	}
+
+	if c.Stats.TopMax <= 0 {
+		return fmt.Errorf("STATS_TOP_MAX deve ser maior que zero")
+	}
+
+	if c.Stats.MaxTransactions <= 0 {
+		return fmt.Errorf("STATS_MAX_TRANSACTIONS deve ser maior que zero")
+	}
+
+	if len(c.Stats.PeriodTimezones) == 0 {
+		return fmt.Errorf("STATS_PERIOD_TIMEZONES deve informar ao menos um fuso horário")
+	}
+
+	for _, name := range c.Stats.PeriodTimezones {
+		if _, err := time.LoadLocation(name); err != nil {
+			return fmt.Errorf("STATS_PERIOD_TIMEZONES contém um fuso horário inválido: %q", name)
+		}
+	}
+
+	if c.Stats.PeriodRetention <= 0 {
+		return fmt.Errorf("STATS_PERIOD_RETENTION deve ser maior que zero")
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_TTL deve ser maior que zero")
+	}
+
+	if c.Idempotency.MaxKeys <= 0 {
+		return fmt.Errorf("IDEMPOTENCY_MAX_KEYS deve ser maior que zero")
+	}
+
+	if c.Ingest.BatchMaxItems <= 0 {
+		return fmt.Errorf("BATCH_MAX_ITEMS deve ser maior que zero")
+	}
+
+	if c.Ingest.StreamMaxLineBytes < 16 {
+		return fmt.Errorf("STREAM_MAX_LINE_BYTES deve ser de pelo menos 16 bytes")
+	}
+
+	if c.Ingest.StreamMaxLines <= 0 {
+		return fmt.Errorf("STREAM_MAX_LINES deve ser maior que zero")
+	}
+
+	if strings.ContainsRune("\"\r\n", c.Ingest.CSVDelimiter) || c.Ingest.CSVDelimiter == utf8.RuneError {
+		return fmt.Errorf("CSV_DELIMITER deve ser um único caractere diferente de aspas e quebra de linha")
+	}
+
+	if c.Validation.MaxValue.Sign() <= 0 {
+		return fmt.Errorf("TRANSACTION_MAX_VALUE deve ser maior que zero")
+	}
+
+	if c.Validation.MaxAge <= 0 {
+		return fmt.Errorf("TRANSACTION_MAX_AGE deve ser maior que zero")
+	}
+
+	if c.Response.Profile != "enveloped" && c.Response.Profile != "spec" {
+		return fmt.Errorf("RESPONSE_PROFILE deve ser enveloped ou spec")
+	}
+
+	if code, err := currency.Normalize(c.Currency.Base); err != nil || code != c.Currency.Base {
+		return fmt.Errorf("CURRENCY_BASE deve ser um código ISO 4217 em letras maiúsculas")
+	}
+
+	if c.Response.ErrorFormat != "json" && c.Response.ErrorFormat != "problem" {
+		return fmt.Errorf("ERROR_FORMAT deve ser json ou problem")
+	}
+
 	if c.Server.Port == "" {
 		return fmt.Errorf("PORT não pode ser vazio")
 	}
@@ -95,6 +309,35 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvList retorna os itens não vazios de uma lista separada por vírgula
+func getEnvList(key, defaultValue string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnvString(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvDecimal(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	if value := os.Getenv(key); value != "" {
+		if decimalValue, err := decimal.Parse(value); err == nil {
+			return decimalValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvRune(key string, defaultValue rune) rune {
+	if value := os.Getenv(key); value != "" {
+		if r, size := utf8.DecodeRuneInString(value); size == len(value) {
+			return r
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
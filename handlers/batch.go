@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"api-itau/internal/models"
+)
+
+const (
+	// defaultBatchMaxItems é o limite padrão de transações por lote
+	defaultBatchMaxItems = 1000
+	// maxBatchBodySize limita o tamanho do corpo de um lote
+	maxBatchBodySize = 10 << 20 // 10 MB
+)
+
+// BatchItemResult representa o resultado de uma transação do lote
+type BatchItemResult struct {
+	Index   int              `json:"indice"`
+	Status  int              `json:"status"`
+	ID      string           `json:"id,omitempty"`
+	Code    string           `json:"codigo,omitempty"`
+	Message string           `json:"mensagem,omitempty"`
+	Details []FieldViolation `json:"detalhes,omitempty"`
+}
+
+// BatchResponse representa a resposta do processamento de um lote
+type BatchResponse struct {
+	Accepted int               `json:"aceitas"`
+	Rejected int               `json:"rejeitadas"`
+	Results  []BatchItemResult `json:"resultados"`
+}
+
+// WithBatchLimit define a quantidade máxima de transações por lote
+func WithBatchLimit(maxItems int) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.batchMaxItems = maxItems
+	}
+}
+
+// HandleBatch processa requisições POST /transacao/lote. Cada item é validado
+// individualmente e as transações válidas são registradas de uma só vez.
+// Com atomic=true, qualquer item inválido rejeita o lote inteiro.
+func (h *TransactionHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	atomic := r.URL.Query().Get("atomic") == "true"
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBatchBodySize+1))
+	if err != nil {
+		h.logger.Error("erro ao ler corpo do lote", "erro", err)
+		h.responder.Error(w, r, http.StatusBadRequest, "invalid_request", "Erro ao ler requisição")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(body) > maxBatchBodySize {
+		h.responder.Error(w, r, http.StatusRequestEntityTooLarge, "batch_too_large", "Corpo do lote excede o tamanho máximo")
+		return
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		h.logger.Error("erro ao decodificar lote", "erro", err)
+		h.responder.Error(w, r, http.StatusBadRequest, "invalid_json", "O lote deve ser um array JSON de transações")
+		return
+	}
+
+	if len(items) == 0 {
+		h.responder.Error(w, r, http.StatusBadRequest, "empty_batch", "O lote não contém transações")
+		return
+	}
+
+	if len(items) > h.batchMaxItems {
+		h.responder.Error(w, r, http.StatusRequestEntityTooLarge, "batch_too_large",
+			fmt.Sprintf("O lote excede o limite de %d transações", h.batchMaxItems))
+		return
+	}
+
+	response := BatchResponse{Results: make([]BatchItemResult, len(items))}
+	transactions := make([]models.Transaction, 0, len(items))
+
+	for i, item := range items {
+		result := BatchItemResult{Index: i}
+
+		if transaction, rejection := h.parseTransaction(item); rejection != nil {
+			result.Status = rejection.status
+			result.Code = rejection.code
+			result.Message = rejection.message
+			result.Details = rejection.details
+		} else {
+			result.Status = http.StatusCreated
+			result.ID = transaction.ID
+			transactions = append(transactions, *transaction)
+		}
+
+		response.Results[i] = result
+	}
+
+	response.Accepted = len(transactions)
+	response.Rejected = len(items) - len(transactions)
+
+	if response.Rejected > 0 && atomic {
+		h.logger.Error("lote atômico rejeitado", "rejeitadas", response.Rejected)
+		for i := range response.Results {
+			if response.Results[i].Status == http.StatusCreated {
+				response.Results[i].Status = http.StatusFailedDependency
+				response.Results[i].ID = ""
+				response.Results[i].Code = "batch_rejected"
+				response.Results[i].Message = "Lote rejeitado por conter transações inválidas"
+			}
+		}
+		response.Accepted = 0
+		response.Rejected = len(items)
+		h.responder.Failure(w, http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	if len(transactions) > 0 {
+		if err := h.service.AddTransactions(transactions); err != nil {
+			h.logger.Error("erro ao adicionar lote de transações", "erro", err)
+			h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro ao processar lote")
+			return
+		}
+	}
+
+	h.logger.Info("lote de transações processado",
+		"aceitas", response.Accepted,
+		"rejeitadas", response.Rejected,
+	)
+
+	switch {
+	case response.Rejected == 0:
+		h.responder.Success(w, http.StatusCreated, response)
+	case response.Accepted == 0:
+		h.responder.Failure(w, http.StatusUnprocessableEntity, response)
+	default:
+		h.responder.Success(w, http.StatusMultiStatus, response)
+	}
+}
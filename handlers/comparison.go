@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"api-itau/pkg/decimal"
+)
+
+// MetricDelta representa a variação de uma métrica entre duas janelas
+type MetricDelta struct {
+	// Absolute é o valor atual menos o anterior
+	Absolute decimal.Decimal `json:"absoluta"`
+	// Percent é a variação em relação ao valor anterior, em porcentagem, ou
+	// nulo quando o valor anterior é zero
+	Percent *float64 `json:"percentual"`
+}
+
+// ComparisonDeltas agrupa a variação de cada métrica das estatísticas
+type ComparisonDeltas struct {
+	Count MetricDelta `json:"count"`
+	Sum   MetricDelta `json:"sum"`
+	Avg   MetricDelta `json:"avg"`
+	Min   MetricDelta `json:"min"`
+	Max   MetricDelta `json:"max"`
+}
+
+// ComparisonResponse compara as estatísticas da janela atual com as da
+// janela de mesma duração imediatamente anterior
+type ComparisonResponse struct {
+	Current  *StatisticsResponse `json:"atual"`
+	Previous *StatisticsResponse `json:"anterior"`
+	Deltas   ComparisonDeltas    `json:"variacao"`
+}
+
+// HandleComparison processa requisições GET /estatistica/comparativo
+func (h *StatisticsHandler) HandleComparison(w http.ResponseWriter, r *http.Request) {
+	comparison, err := h.service.Compare()
+	if err != nil {
+		h.logger.Error("erro ao comparar janelas", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("comparativo de janelas retornado com sucesso",
+		"countAtual", comparison.Current.Count,
+		"countAnterior", comparison.Previous.Count,
+	)
+
+	h.responder.Success(w, http.StatusOK, comparison)
+}
@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"api-itau/internal/models"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/validator"
+)
+
+const (
+	// defaultCSVDelimiter é o delimitador padrão de importação e exportação
+	defaultCSVDelimiter = ','
+	// exportFlushRows é a quantidade de linhas enviadas ao cliente por vez
+	exportFlushRows = 1000
+)
+
+// csvColumns indica a posição das colunas de uma importação CSV. As colunas
+// moeda, tipo, descricao e rotulos são opcionais e valem -1 quando ausentes.
+type csvColumns struct {
+	value       int
+	timestamp   int
+	currency    int
+	kind        int
+	description int
+	labels      int
+}
+
+// defaultCSVColumns é a ordem das colunas de um CSV sem cabeçalho
+var defaultCSVColumns = csvColumns{value: 0, timestamp: 1, currency: 2, kind: 3, description: 4, labels: 5}
+
+// WithCSVDelimiter define o delimitador padrão de importação e exportação CSV
+func WithCSVDelimiter(delimiter rune) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.csvDelimiter = delimiter
+	}
+}
+
+// HandleImport processa requisições POST /transacao/importar com transações
+// em CSV (text/csv). Cada linha deve conter as colunas valor e dataHora, e
+// opcionalmente moeda, tipo, descricao e rotulos; um cabeçalho com esses
+// nomes é detectado na primeira linha e pode alterar a ordem das colunas.
+// Valores com vírgula decimal (ex.: 1.234,56) são aceitos, e os rótulos usam
+// o formato de query string (ex.: canal=app&loja=0042).
+func (h *TransactionHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/csv" {
+		h.responder.Error(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"Content-Type deve ser text/csv")
+		return
+	}
+	defer r.Body.Close()
+
+	delimiter, err := h.parseDelimiter(r)
+	if err != nil {
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	h.clearDeadlines(w)
+
+	reader := csv.NewReader(r.Body)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	ingest := newIngester(h.service)
+	summary := &ingest.summary
+	columns := defaultCSVColumns
+	firstRecord := true
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var parseErr *csv.ParseError
+		malformed := errors.As(err, &parseErr)
+		if err != nil && !malformed {
+			h.logger.Error("erro ao ler CSV de transações", "erro", err)
+			h.responder.Error(w, r, http.StatusBadRequest, "invalid_request", "Erro ao ler requisição")
+			return
+		}
+
+		if firstRecord && !malformed {
+			// Planilhas exportadas pelo Excel costumam iniciar com um BOM
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+			if header, ok := detectCSVHeader(record); ok {
+				firstRecord = false
+				columns = header
+				continue
+			}
+		}
+		firstRecord = false
+
+		summary.Lines++
+		if summary.Lines > h.streamMaxLines {
+			summary.Lines--
+			h.respondTooManyLines(w, r, ingest)
+			return
+		}
+
+		if malformed {
+			ingest.reject(parseErr.StartLine, "invalid_csv", "Linha CSV malformada")
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		transaction, rejection := h.parseCSVRecord(record, columns)
+		if rejection != nil {
+			ingest.reject(line, rejection.code, rejection.message, rejection.details...)
+			continue
+		}
+
+		if err := ingest.accept(*transaction); err != nil {
+			h.respondIngestFailure(w, r, err)
+			return
+		}
+	}
+
+	if err := ingest.flush(); err != nil {
+		h.respondIngestFailure(w, r, err)
+		return
+	}
+
+	h.logger.Info("importação CSV processada",
+		"linhas", summary.Lines,
+		"aceitas", summary.Accepted,
+		"rejeitadas", summary.Rejected,
+	)
+
+	h.responder.Success(w, http.StatusOK, *summary)
+}
+
+// HandleExport processa requisições GET /transacao/exportar, enviando as
+// transações ainda dentro do período de retenção em CSV, em ordem cronológica.
+// O parâmetro decimal=virgula formata os valores com vírgula decimal.
+func (h *TransactionHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	delimiter, err := h.parseDelimiter(r)
+	if err != nil {
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	decimalComma := false
+	switch r.URL.Query().Get("decimal") {
+	case "", "ponto":
+	case "virgula":
+		decimalComma = true
+	default:
+		h.responder.Error(w, r, http.StatusBadRequest, "invalid_decimal",
+			"O parâmetro decimal deve ser ponto ou virgula")
+		return
+	}
+
+	h.clearDeadlines(w)
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="transacoes.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+	rc := http.NewResponseController(w)
+
+	rows := 0
+	writer.Write([]string{"id", "valor", "dataHora", "moeda", "tipo", "descricao", "rotulos"})
+	err = h.service.EachTransaction(func(t models.Transaction) error {
+		value := t.Value.String()
+		if decimalComma {
+			value = strings.Replace(value, ".", ",", 1)
+		}
+
+		record := []string{t.ID, value, t.Timestamp.Format(time.RFC3339Nano), t.Currency, t.Type,
+			escapeCSVFormula(t.Description), escapeCSVFormula(formatCSVLabels(t.Labels))}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%exportFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			rc.Flush()
+		}
+		return nil
+	})
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+
+	// O status já foi enviado, então a falha só pode ser registrada
+	if err != nil {
+		h.logger.Error("erro ao exportar transações", "erro", err, "linhas", rows)
+		return
+	}
+
+	h.logger.Info("exportação CSV concluída", "linhas", rows)
+}
+
+// escapeCSVFormula prefixa com ' um texto livre que seria interpretado como
+// fórmula ao abrir a exportação em uma planilha (injeção de fórmula)
+func escapeCSVFormula(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+// parseDelimiter retorna o delimitador do parâmetro delimitador ou o padrão
+func (h *TransactionHandler) parseDelimiter(r *http.Request) (rune, error) {
+	raw := r.URL.Query().Get("delimitador")
+	if raw == "" {
+		return h.csvDelimiter, nil
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(raw)
+	if size != len(raw) || delimiter == utf8.RuneError || strings.ContainsRune("\"\r\n", delimiter) {
+		return 0, &queryError{
+			code:    "invalid_delimiter",
+			message: "O delimitador deve ser um único caractere diferente de aspas e quebra de linha",
+		}
+	}
+
+	return delimiter, nil
+}
+
+// detectCSVHeader reconhece uma linha de cabeçalho com as colunas valor e
+// dataHora (e opcionalmente moeda, tipo, descricao e rotulos), em qualquer
+// ordem e sem diferenciar maiúsculas de minúsculas
+func detectCSVHeader(record []string) (csvColumns, bool) {
+	columns := csvColumns{value: -1, timestamp: -1, currency: -1, kind: -1, description: -1, labels: -1}
+	for i, field := range record {
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "valor":
+			columns.value = i
+		case "datahora":
+			columns.timestamp = i
+		case "moeda":
+			columns.currency = i
+		case "tipo":
+			columns.kind = i
+		case "descricao", "descrição":
+			columns.description = i
+		case "rotulos", "rótulos":
+			columns.labels = i
+		}
+	}
+
+	return columns, columns.value >= 0 && columns.timestamp >= 0
+}
+
+// parseCSVRecord converte uma linha CSV em uma transação validada. Colunas
+// vazias são tratadas como campos ausentes.
+func (h *TransactionHandler) parseCSVRecord(record []string, columns csvColumns) (*models.Transaction, *rejection) {
+	if columns.value >= len(record) || columns.timestamp >= len(record) {
+		return nil, &rejection{
+			status:  http.StatusBadRequest,
+			code:    "invalid_row",
+			message: "A linha deve conter as colunas valor e dataHora",
+		}
+	}
+
+	req := TransactionRequest{
+		Currency:    optionalCSVField(record, columns.currency),
+		Type:        optionalCSVField(record, columns.kind),
+		Description: optionalCSVField(record, columns.description),
+	}
+
+	if raw := strings.TrimSpace(record[columns.value]); raw != "" {
+		parsed, err := parseDecimal(raw)
+		if err != nil {
+			return nil, &rejection{
+				status:  http.StatusBadRequest,
+				code:    "invalid_value",
+				message: fmt.Sprintf("Valor inválido: %q", raw),
+				details: []FieldViolation{{
+					Field:   fieldValue,
+					Rule:    "formato",
+					Message: "valor deve ser um número",
+					Value:   raw,
+				}},
+				err: err,
+			}
+		}
+		req.Value = &parsed
+	}
+
+	if raw := strings.TrimSpace(record[columns.timestamp]); raw != "" {
+		parsed, err := validator.ParseTimestamp(raw)
+		if err != nil {
+			return nil, &rejection{
+				status:  http.StatusBadRequest,
+				code:    "invalid_timestamp",
+				message: fmt.Sprintf("Data inválida: %q", raw),
+				details: []FieldViolation{{
+					Field:   fieldTimestamp,
+					Rule:    "formato",
+					Message: validator.ErrInvalidTimestamp.Error(),
+					Value:   raw,
+				}},
+				err: err,
+			}
+		}
+		req.Timestamp = &parsed
+	}
+
+	if raw := optionalCSVField(record, columns.labels); raw != "" {
+		labels, err := parseCSVLabels(raw)
+		if err != nil {
+			return nil, &rejection{
+				status:  http.StatusBadRequest,
+				code:    "invalid_labels",
+				message: fmt.Sprintf("Rótulos inválidos: %q", raw),
+				details: []FieldViolation{{
+					Field:   fieldLabels,
+					Rule:    "formato",
+					Message: "rotulos deve estar no formato nome=valor&nome=valor",
+					Value:   raw,
+				}},
+				err: err,
+			}
+		}
+		req.Labels = labels
+	}
+
+	return h.newTransaction(req)
+}
+
+// errDuplicateLabel indica um rótulo repetido na coluna rotulos
+var errDuplicateLabel = errors.New("rótulo repetido")
+
+// parseCSVLabels converte a coluna rotulos, no formato de query string
+// (nome=valor&nome=valor, com escape por %), nos rótulos da transação
+func parseCSVLabels(raw string) (map[string]string, error) {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string, len(values))
+	for name, value := range values {
+		if len(value) > 1 {
+			return nil, fmt.Errorf("%w: %q", errDuplicateLabel, name)
+		}
+		labels[name] = value[0]
+	}
+	return labels, nil
+}
+
+// formatCSVLabels formata os rótulos para a coluna rotulos, ordenados pelo
+// nome. É o inverso de parseCSVLabels.
+func formatCSVLabels(labels map[string]string) string {
+	values := make(url.Values, len(labels))
+	for name, value := range labels {
+		values.Set(name, value)
+	}
+	return values.Encode()
+}
+
+// optionalCSVField retorna o conteúdo de uma coluna opcional, ou vazio quando
+// a coluna não existe no arquivo ou na linha
+func optionalCSVField(record []string, column int) string {
+	if column < 0 || column >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[column])
+}
+
+// parseDecimal converte um valor numérico em notação internacional (1234.56)
+// ou brasileira (1.234,56). A vírgula, quando presente, é o separador decimal
+// e os pontos são tratados como separadores de milhar.
+func parseDecimal(raw string) (decimal.Decimal, error) {
+	s := strings.TrimSpace(raw)
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	}
+
+	return decimal.Parse(s)
+}
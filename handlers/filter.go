@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"api-itau/internal/models"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/validator"
+)
+
+// TransactionFilter restringe as transações consideradas nas estatísticas.
+// Todos os critérios informados devem ser satisfeitos. O filtro também é
+// enviado na resposta para indicar quais critérios foram aplicados.
+type TransactionFilter struct {
+	// ValueMin é o menor valor aceito (inclusivo)
+	ValueMin *decimal.Decimal `json:"valorMin,omitempty"`
+	// ValueMax é o maior valor aceito (inclusivo)
+	ValueMax *decimal.Decimal `json:"valorMax,omitempty"`
+	// Labels são os rótulos que a transação deve possuir com o valor informado
+	Labels map[string]string `json:"rotulos,omitempty"`
+}
+
+// Empty indica se nenhum critério foi informado
+func (f *TransactionFilter) Empty() bool {
+	return f.ValueMin == nil && f.ValueMax == nil && len(f.Labels) == 0
+}
+
+// Match indica se a transação satisfaz todos os critérios do filtro
+func (f *TransactionFilter) Match(t models.Transaction) bool {
+	if f.ValueMin != nil && t.Value.Cmp(*f.ValueMin) < 0 {
+		return false
+	}
+	if f.ValueMax != nil && t.Value.Cmp(*f.ValueMax) > 0 {
+		return false
+	}
+	for name, value := range f.Labels {
+		if t.Labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// parseFilter extrai os filtros valorMin, valorMax e rotulo (no formato
+// chave:valor, repetível) da query string
+func parseFilter(values url.Values) (TransactionFilter, error) {
+	var filter TransactionFilter
+
+	for _, param := range []struct {
+		name   string
+		target **decimal.Decimal
+	}{
+		{"valorMin", &filter.ValueMin},
+		{"valorMax", &filter.ValueMax},
+	} {
+		if !values.Has(param.name) {
+			continue
+		}
+		value, err := decimal.Parse(strings.TrimSpace(values.Get(param.name)))
+		if err != nil {
+			return filter, &queryError{
+				code:    "invalid_filter",
+				message: fmt.Sprintf("%s deve ser um número", param.name),
+			}
+		}
+		*param.target = &value
+	}
+
+	if filter.ValueMin != nil && filter.ValueMax != nil && filter.ValueMin.Cmp(*filter.ValueMax) > 0 {
+		return filter, &queryError{
+			code:    "invalid_filter",
+			message: "valorMin não pode ser maior que valorMax",
+		}
+	}
+
+	labels := values["rotulo"]
+	if len(labels) > validator.MaxLabels {
+		return filter, &queryError{
+			code:    "invalid_filter",
+			message: fmt.Sprintf("no máximo %d filtros de rótulo podem ser informados", validator.MaxLabels),
+		}
+	}
+
+	for _, raw := range labels {
+		name, value, ok := strings.Cut(raw, ":")
+		if !ok || !validator.IsLabelKey(name) || value == "" {
+			return filter, &queryError{
+				code:    "invalid_filter",
+				message: fmt.Sprintf("rotulo deve estar no formato chave:valor: %q", raw),
+			}
+		}
+
+		if filter.Labels == nil {
+			filter.Labels = make(map[string]string)
+		}
+		if previous, ok := filter.Labels[name]; ok && previous != value {
+			return filter, &queryError{
+				code:    "invalid_filter",
+				message: fmt.Sprintf("o rótulo %s foi informado com valores diferentes", name),
+			}
+		}
+		filter.Labels[name] = value
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"api-itau/pkg/decimal"
+)
+
+// HistogramBucket representa uma faixa de valor do histograma. A faixa
+// inclui os valores maiores que Lower e menores ou iguais a Upper; a
+// primeira faixa não tem limite inferior e a última não tem limite superior.
+type HistogramBucket struct {
+	Lower *decimal.Decimal `json:"de,omitempty"`
+	Upper *decimal.Decimal `json:"ate,omitempty"`
+	Count int              `json:"count"`
+}
+
+// HistogramResponse representa a distribuição dos valores das transações da janela
+type HistogramResponse struct {
+	Count   int               `json:"count"`
+	Buckets []HistogramBucket `json:"faixas"`
+}
+
+// HandleHistogram processa requisições GET /estatistica/histograma,
+// retornando a quantidade de transações da janela em cada faixa de valor
+func (h *StatisticsHandler) HandleHistogram(w http.ResponseWriter, r *http.Request) {
+	histogram, err := h.service.Histogram()
+	if err != nil {
+		h.logger.Error("erro ao obter histograma", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("histograma retornado com sucesso",
+		"count", histogram.Count,
+		"faixas", len(histogram.Buckets),
+	)
+
+	h.responder.Success(w, http.StatusOK, histogram)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"api-itau/internal/idempotency"
+)
+
+const (
+	// IdempotencyKeyHeader é o header usado pelos clientes para identificar repetições
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader sinaliza que a resposta é uma repetição da original
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength limita o tamanho da chave aceita
+	maxIdempotencyKeyLength = 255
+)
+
+// WithIdempotency habilita o suporte ao header Idempotency-Key no POST /transacao
+func WithIdempotency(store *idempotency.Store) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.idempotency = store
+	}
+}
+
+// handleIdempotentPost processa uma criação de transação identificada por
+// uma chave de idempotência. A primeira requisição é processada e sua
+// resposta armazenada; repetições com o mesmo corpo recebem a resposta
+// original sem nova inclusão da transação.
+func (h *TransactionHandler) handleIdempotentPost(w http.ResponseWriter, r *http.Request, key string, body []byte) {
+	if len(key) > maxIdempotencyKeyLength {
+		h.responder.Error(w, r, http.StatusBadRequest, "invalid_idempotency_key", "Chave de idempotência muito longa")
+		return
+	}
+
+	stored, err := h.idempotency.Begin(key, idempotency.Fingerprint(body))
+	switch {
+	case errors.Is(err, idempotency.ErrFingerprintMismatch):
+		h.logger.Error("chave de idempotência reutilizada", "chave", key)
+		h.responder.Error(w, r, http.StatusUnprocessableEntity, "idempotency_key_reused",
+			"Chave de idempotência já utilizada com outro corpo de requisição")
+		return
+	case errors.Is(err, idempotency.ErrKeyInProgress):
+		h.responder.Error(w, r, http.StatusConflict, "idempotency_key_in_progress",
+			"Requisição com a mesma chave de idempotência em andamento")
+		return
+	case stored != nil:
+		h.logger.Info("resposta idempotente repetida", "chave", key, "status", stored.StatusCode)
+		replay(w, stored)
+		return
+	}
+
+	// Erros internos e pânicos não são armazenados: a chave é liberada para
+	// que o cliente possa tentar novamente, em vez de ficar em andamento
+	// até expirar
+	completed := false
+	defer func() {
+		if !completed {
+			h.idempotency.Release(key)
+		}
+	}()
+
+	rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	h.createTransaction(rec, r, body)
+
+	if rec.statusCode >= http.StatusInternalServerError {
+		return
+	}
+
+	h.idempotency.Complete(key, idempotency.Response{
+		StatusCode: rec.statusCode,
+		Header:     replayableHeaders(w.Header()),
+		Body:       rec.body.Bytes(),
+	})
+	completed = true
+}
+
+// replayableHeaders seleciona os headers da resposta que fazem parte do
+// resultado da operação. Headers da requisição atual, como X-Request-ID,
+// não são repetidos.
+func replayableHeaders(header http.Header) http.Header {
+	stored := make(http.Header)
+	for _, name := range []string{"Content-Type", "Location"} {
+		if value := header.Get(name); value != "" {
+			stored.Set(name, value)
+		}
+	}
+	return stored
+}
+
+// replay escreve uma resposta armazenada
+func replay(w http.ResponseWriter, stored *idempotency.Response) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	w.Write(stored.Body)
+}
+
+// responseRecorder repassa a resposta ao cliente enquanto guarda uma cópia
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"api-itau/internal/models"
+)
+
+const (
+	// ingestChunkSize é a quantidade de transações registradas por vez
+	ingestChunkSize = 500
+	// maxReportedIngestErrors limita os erros detalhados no resumo
+	maxReportedIngestErrors = 20
+)
+
+// IngestLineError descreve a rejeição de uma linha de uma importação
+type IngestLineError struct {
+	Line    int              `json:"linha"`
+	Code    string           `json:"codigo"`
+	Message string           `json:"mensagem"`
+	Details []FieldViolation `json:"detalhes,omitempty"`
+}
+
+// IngestResponse resume o processamento de uma importação linha a linha
+type IngestResponse struct {
+	Lines    int               `json:"linhas"`
+	Accepted int               `json:"aceitas"`
+	Rejected int               `json:"rejeitadas"`
+	Errors   []IngestLineError `json:"erros"`
+}
+
+// ingester acumula as transações de uma importação e as registra em blocos,
+// mantendo o resumo de linhas aceitas e rejeitadas
+type ingester struct {
+	service TransactionService
+	chunk   []models.Transaction
+	summary IngestResponse
+}
+
+// newIngester cria um ingester que registra as transações no serviço informado
+func newIngester(service TransactionService) *ingester {
+	return &ingester{
+		service: service,
+		chunk:   make([]models.Transaction, 0, ingestChunkSize),
+		summary: IngestResponse{Errors: make([]IngestLineError, 0)},
+	}
+}
+
+// accept inclui uma transação válida, registrando o bloco quando ele enche
+func (i *ingester) accept(t models.Transaction) error {
+	i.chunk = append(i.chunk, t)
+	if len(i.chunk) < ingestChunkSize {
+		return nil
+	}
+	return i.flush()
+}
+
+// reject contabiliza uma linha rejeitada, detalhando apenas as primeiras
+func (i *ingester) reject(line int, code, message string, details ...FieldViolation) {
+	i.summary.Rejected++
+	if len(i.summary.Errors) < maxReportedIngestErrors {
+		i.summary.Errors = append(i.summary.Errors, IngestLineError{
+			Line:    line,
+			Code:    code,
+			Message: message,
+			Details: details,
+		})
+	}
+}
+
+// flush registra as transações pendentes
+func (i *ingester) flush() error {
+	if len(i.chunk) == 0 {
+		return nil
+	}
+	if err := i.service.AddTransactions(i.chunk); err != nil {
+		return err
+	}
+	i.summary.Accepted += len(i.chunk)
+	i.chunk = i.chunk[:0]
+	return nil
+}
+
+// clearDeadlines remove os deadlines de leitura e escrita da conexão, já que
+// cargas e exportações em massa podem exceder os timeouts do servidor
+func (h *TransactionHandler) clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		h.logger.Error("não foi possível remover o deadline de leitura", "erro", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Error("não foi possível remover o deadline de escrita", "erro", err)
+	}
+}
+
+// respondTooManyLines registra as transações pendentes de uma importação que
+// excedeu o limite de linhas e responde com o resumo processado até o limite
+func (h *TransactionHandler) respondTooManyLines(w http.ResponseWriter, r *http.Request, ingest *ingester) {
+	if err := ingest.flush(); err != nil {
+		h.respondIngestFailure(w, r, err)
+		return
+	}
+
+	h.logger.Error("importação excedeu o limite de linhas", "limite", h.streamMaxLines)
+	h.responder.apiError(w, r, http.StatusRequestEntityTooLarge, &APIError{
+		Code:    "too_many_lines",
+		Message: fmt.Sprintf("A importação excede o limite de %d linhas", h.streamMaxLines),
+	}, ingest.summary)
+}
+
+// respondIngestFailure responde a uma falha ao registrar um bloco de transações
+func (h *TransactionHandler) respondIngestFailure(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error("erro ao adicionar transações importadas", "erro", err)
+	h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro ao processar transações")
+}
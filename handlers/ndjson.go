@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	// defaultStreamMaxLineBytes é o tamanho máximo padrão de uma linha NDJSON
+	defaultStreamMaxLineBytes = 64 << 10 // 64 KB
+	// defaultStreamMaxLines é a quantidade máxima padrão de linhas por requisição
+	defaultStreamMaxLines = 1000000
+)
+
+// WithStreamLimits define o tamanho máximo de uma linha e a quantidade
+// máxima de linhas aceitas no POST /transacao/stream
+func WithStreamLimits(maxLineBytes, maxLines int) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.streamMaxLineBytes = maxLineBytes
+		h.streamMaxLines = maxLines
+	}
+}
+
+// HandleStream processa requisições POST /transacao/stream com uma transação
+// por linha (application/x-ndjson). O corpo é lido de forma incremental, sem
+// limite de tamanho total, e as transações válidas são registradas em blocos.
+func (h *TransactionHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-ndjson" {
+		h.responder.Error(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type",
+			"Content-Type deve ser application/x-ndjson")
+		return
+	}
+	defer r.Body.Close()
+
+	h.clearDeadlines(w)
+
+	ingest := newIngester(h.service)
+	summary := &ingest.summary
+
+	// As linhas são lidas com ReadLine, e não com um json.Decoder sobre o
+	// corpo, para que o limite STREAM_MAX_LINE_BYTES seja aplicado antes de
+	// a linha ser decodificada: o Decoder acumula o valor inteiro em memória
+	// e não tem noção de linha. Cada linha é decodificada com json.Unmarshal, que
+	// rejeita dados após o valor JSON (ex.: "{...} {...}" na mesma linha).
+	// O buffer comporta uma linha do tamanho máximo seguida de "\r\n".
+	reader := bufio.NewReaderSize(r.Body, h.streamMaxLineBytes+2)
+	for lineNumber := 1; ; lineNumber++ {
+		line, tooLong, err := readLine(reader, h.streamMaxLineBytes)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			h.logger.Error("erro ao ler stream de transações", "erro", err)
+			h.responder.Error(w, r, http.StatusBadRequest, "invalid_request", "Erro ao ler requisição")
+			return
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 && !tooLong {
+			continue
+		}
+
+		summary.Lines++
+		if summary.Lines > h.streamMaxLines {
+			summary.Lines--
+			h.respondTooManyLines(w, r, ingest)
+			return
+		}
+
+		if tooLong {
+			ingest.reject(lineNumber, "line_too_long",
+				fmt.Sprintf("A linha excede o limite de %d bytes", h.streamMaxLineBytes))
+			continue
+		}
+
+		transaction, rejection := h.parseTransaction(line)
+		if rejection != nil {
+			ingest.reject(lineNumber, rejection.code, rejection.message, rejection.details...)
+			continue
+		}
+
+		if err := ingest.accept(*transaction); err != nil {
+			h.respondIngestFailure(w, r, err)
+			return
+		}
+	}
+
+	if err := ingest.flush(); err != nil {
+		h.respondIngestFailure(w, r, err)
+		return
+	}
+
+	h.logger.Info("stream de transações processado",
+		"linhas", summary.Lines,
+		"aceitas", summary.Accepted,
+		"rejeitadas", summary.Rejected,
+	)
+
+	h.responder.Success(w, http.StatusOK, *summary)
+}
+
+// readLine lê a próxima linha sem o terminador. Quando a linha excede
+// maxBytes, tooLong é verdadeiro e o restante da linha que não coube no
+// buffer do reader é descartado.
+func readLine(reader *bufio.Reader, maxBytes int) (line []byte, tooLong bool, err error) {
+	line, isPrefix, err := reader.ReadLine()
+	if err != nil {
+		return nil, false, err
+	}
+
+	tooLong = len(line) > maxBytes
+	for isPrefix {
+		tooLong = true
+		_, isPrefix, err = reader.ReadLine()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	return line, tooLong, nil
+}
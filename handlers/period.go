@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"api-itau/pkg/utils"
+	"api-itau/pkg/validator"
+)
+
+// PeriodQuery define a consulta de estatísticas por período de calendário
+type PeriodQuery struct {
+	// Granularity é utils.GranularityMinute, utils.GranularityHour ou utils.GranularityDay
+	Granularity string
+	// Timezone é o fuso horário ao qual os períodos são alinhados
+	Timezone string
+	// Window é o intervalo consultado; os períodos que se sobrepõem a ele são retornados
+	Window utils.TimeWindow
+}
+
+// periodDurations são as durações nominais de cada granularidade, usadas
+// para limitar a quantidade de períodos de uma consulta
+var periodDurations = map[string]time.Duration{
+	utils.GranularityMinute: time.Minute,
+	utils.GranularityHour:   time.Hour,
+	utils.GranularityDay:    24 * time.Hour,
+}
+
+// HandlePeriods processa requisições GET /estatistica/periodo, retornando
+// as estatísticas de cada minuto, hora ou dia de calendário no fuso horário
+// solicitado
+func (h *StatisticsHandler) HandlePeriods(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePeriodQuery(r, h.service.Now(), h.service.PeriodTimezones(), h.service.PeriodRetention())
+	if err != nil {
+		h.logger.Error("parâmetros do período inválidos", "erro", err)
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	points, err := h.service.GetPeriods(query)
+	if err != nil {
+		h.logger.Error("erro ao obter estatísticas por período", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("estatísticas por período retornadas com sucesso",
+		"granularidade", query.Granularity,
+		"tz", query.Timezone,
+		"periodos", len(points),
+	)
+
+	h.responder.Success(w, http.StatusOK, points)
+}
+
+// parsePeriodQuery extrai a granularidade, o fuso horário e o intervalo da
+// consulta. Por padrão são retornados os minutos da hora, as horas do dia ou
+// os dias do período de retenção até now.
+func parsePeriodQuery(r *http.Request, now time.Time, timezones []string, retention time.Duration) (PeriodQuery, error) {
+	values := r.URL.Query()
+
+	query := PeriodQuery{Granularity: utils.GranularityHour}
+	if values.Has("granularidade") {
+		query.Granularity = strings.ToLower(strings.TrimSpace(values.Get("granularidade")))
+	}
+	nominal, ok := periodDurations[query.Granularity]
+	if !ok {
+		return query, &queryError{
+			code:    "invalid_granularity",
+			message: utils.ErrInvalidGranularity.Error(),
+		}
+	}
+
+	if len(timezones) > 0 {
+		query.Timezone = timezones[0]
+	}
+	if values.Has("tz") {
+		query.Timezone = strings.TrimSpace(values.Get("tz"))
+	}
+	if !slices.Contains(timezones, query.Timezone) {
+		return query, &queryError{
+			code:    "invalid_timezone",
+			message: fmt.Sprintf("tz deve ser um dos fusos horários configurados: %s", strings.Join(timezones, ", ")),
+		}
+	}
+	location, err := time.LoadLocation(query.Timezone)
+	if err != nil {
+		return query, &queryError{code: "invalid_timezone", message: fmt.Sprintf("fuso horário inválido: %q", query.Timezone)}
+	}
+
+	end := now
+	if values.Has("fim") {
+		parsed, err := validator.ParseTimestamp(values.Get("fim"))
+		if err != nil {
+			return query, &queryError{code: "invalid_range", message: "fim inválido"}
+		}
+		end = parsed
+	}
+
+	oldest := now.Add(-retention)
+	start := oldest
+	switch query.Granularity {
+	case utils.GranularityMinute:
+		period, _ := utils.CalendarPeriod(now, utils.GranularityHour, location)
+		start = period.Start
+	case utils.GranularityHour:
+		period, _ := utils.CalendarPeriod(now, utils.GranularityDay, location)
+		start = period.Start
+	}
+	if start.Before(oldest) {
+		start = oldest
+	}
+
+	if values.Has("inicio") {
+		parsed, err := validator.ParseTimestamp(values.Get("inicio"))
+		if err != nil {
+			return query, &queryError{code: "invalid_range", message: "inicio inválido"}
+		}
+		start = parsed
+	}
+
+	if !start.Before(end) {
+		return query, &queryError{
+			code:    "invalid_range",
+			message: "inicio deve ser anterior a fim",
+		}
+	}
+
+	if start.Before(oldest) {
+		return query, &queryError{
+			code:    "range_out_of_retention",
+			message: fmt.Sprintf("inicio não pode ser anterior à retenção de %s", retention),
+		}
+	}
+
+	if end.Sub(start)/nominal >= maxSeriesPoints {
+		return query, &queryError{
+			code:    "too_many_points",
+			message: fmt.Sprintf("a consulta não pode ter mais de %d períodos", maxSeriesPoints),
+		}
+	}
+
+	query.Window = utils.NewTimeWindow(start, end)
+	return query, nil
+}
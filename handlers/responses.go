@@ -3,6 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"api-itau/internal/middleware"
+	"api-itau/internal/problem"
 )
 
 // APIResponse é a estrutura base para todas as respostas da API
@@ -14,8 +17,9 @@ type APIResponse struct {
 
 // APIError representa um erro na API
 type APIError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code    string           `json:"code"`
+	Message string           `json:"message"`
+	Details []FieldViolation `json:"details,omitempty"`
 }
 
 // RespondWithJSON envia uma resposta JSON com o status code apropriado
@@ -35,21 +39,143 @@ func RespondWithJSON(w http.ResponseWriter, statusCode int, payload interface{})
 	}
 }
 
-// RespondWithError envia uma resposta de erro padronizada
-func RespondWithError(w http.ResponseWriter, statusCode int, code string, message string) {
+// ErrorFormat define o formato das respostas de erro
+type ErrorFormat string
+
+const (
+	// ErrorFormatJSON envia os erros no envelope APIResponse, exceto quando o
+	// cliente solicita application/problem+json no header Accept
+	ErrorFormatJSON ErrorFormat = "json"
+	// ErrorFormatProblem envia todos os erros como application/problem+json
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+// ResponseProfile define o formato dos corpos de resposta de todos os endpoints
+type ResponseProfile string
+
+const (
+	// ProfileEnveloped envia as respostas no envelope APIResponse
+	ProfileEnveloped ResponseProfile = "enveloped"
+	// ProfileSpec segue a especificação do desafio: POST e DELETE /transacao e
+	// todos os erros respondem sem corpo, e os demais endpoints enviam os dados
+	// sem envelope. Neste perfil o ErrorFormat não é usado.
+	ProfileSpec ResponseProfile = "spec"
+)
+
+// Responder envia as respostas de um handler no ResponseProfile e no
+// ErrorFormat configurados. O valor zero usa o perfil enveloped e o formato
+// json.
+type Responder struct {
+	profile     ResponseProfile
+	errorFormat ErrorFormat
+}
+
+// NewResponder cria um Responder com o perfil e o formato de erro informados
+func NewResponder(profile ResponseProfile, format ErrorFormat) Responder {
+	return Responder{
+		profile:     profile,
+		errorFormat: format,
+	}
+}
+
+// spec indica se as respostas seguem o perfil da especificação
+func (res Responder) spec() bool {
+	return res.profile == ProfileSpec
+}
+
+// Error envia uma resposta de erro padronizada
+func (res Responder) Error(w http.ResponseWriter, r *http.Request, statusCode int, code string, message string) {
+	res.ErrorDetails(w, r, statusCode, code, message, nil)
+}
+
+// ErrorDetails envia uma resposta de erro padronizada com as violações de
+// cada campo da requisição
+func (res Responder) ErrorDetails(w http.ResponseWriter, r *http.Request, statusCode int, code string, message string, details []FieldViolation) {
+	res.apiError(w, r, statusCode, &APIError{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}, nil)
+}
+
+// InternalError envia um erro 500. É usado pelo RecoveryMiddleware para que
+// os pânicos recuperados sigam o formato dos demais erros.
+func (res Responder) InternalError(w http.ResponseWriter, r *http.Request) {
+	res.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+}
+
+// apiError envia o erro no formato configurado ou negociado pelo header
+// Accept, ou apenas o status no perfil spec. data acompanha o erro no campo
+// data do envelope ou no membro de extensão "data" do problem+json.
+func (res Responder) apiError(w http.ResponseWriter, r *http.Request, statusCode int, apiErr *APIError, data interface{}) {
+	if res.spec() {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if res.errorFormat == ErrorFormatProblem || problem.Accepted(r) {
+		writeProblem(w, r, statusCode, apiErr, data)
+		return
+	}
+
 	RespondWithJSON(w, statusCode, APIResponse{
 		Success: false,
-		Error: &APIError{
-			Code:    code,
-			Message: message,
-		},
+		Data:    data,
+		Error:   apiErr,
 	})
 }
 
-// RespondWithSuccess envia uma resposta de sucesso padronizada
-func RespondWithSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
+// writeProblem converte o erro em um problema RFC 7807
+func writeProblem(w http.ResponseWriter, r *http.Request, statusCode int, apiErr *APIError, data interface{}) {
+	p := problem.New(statusCode, apiErr.Code, apiErr.Message)
+	p.Instance = middleware.GetRequestID(r.Context())
+	if len(apiErr.Details) > 0 {
+		p.With("details", apiErr.Details)
+	}
+	if data != nil {
+		p.With("data", data)
+	}
+
+	problem.Write(w, p)
+}
+
+// Success envia uma resposta de sucesso padronizada. No perfil spec, os
+// dados são enviados sem o envelope.
+func (res Responder) Success(w http.ResponseWriter, statusCode int, data interface{}) {
+	if res.spec() {
+		RespondWithJSON(w, statusCode, data)
+		return
+	}
+
 	RespondWithJSON(w, statusCode, APIResponse{
 		Success: true,
 		Data:    data,
 	})
 }
+
+// Ack confirma uma operação cujo corpo é dispensado pela especificação: no
+// perfil spec apenas o status é enviado, e no perfil enveloped a resposta é
+// igual à de Success
+func (res Responder) Ack(w http.ResponseWriter, statusCode int, data interface{}) {
+	if res.spec() {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	res.Success(w, statusCode, data)
+}
+
+// Failure envia os dados de uma operação que não foi bem-sucedida, como o
+// resultado de um lote rejeitado. No perfil spec, os dados são enviados sem
+// o envelope.
+func (res Responder) Failure(w http.ResponseWriter, statusCode int, data interface{}) {
+	if res.spec() {
+		RespondWithJSON(w, statusCode, data)
+		return
+	}
+
+	RespondWithJSON(w, statusCode, APIResponse{
+		Success: false,
+		Data:    data,
+	})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"api-itau/pkg/utils"
+	"api-itau/pkg/validator"
+)
+
+const (
+	// defaultSeriesStep é o intervalo entre os pontos quando passo não é informado
+	defaultSeriesStep = 10 * time.Second
+	// maxSeriesPoints limita a quantidade de pontos de uma série
+	maxSeriesPoints = 1000
+)
+
+// SeriesPoint representa as estatísticas de um intervalo da série temporal
+type SeriesPoint struct {
+	Start time.Time `json:"inicio"`
+	End   time.Time `json:"fim"`
+	StatisticsResponse
+}
+
+// HandleSeries processa requisições GET /estatistica/serie, retornando as
+// estatísticas em intervalos de tamanho passo, alinhados a múltiplos do passo
+func (h *StatisticsHandler) HandleSeries(w http.ResponseWriter, r *http.Request) {
+	window, step, err := parseSeriesQuery(r, h.service.Now(), h.service.Retention())
+	if err != nil {
+		h.logger.Error("parâmetros da série inválidos", "erro", err)
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	points, err := h.service.GetSeries(window, step)
+	if err != nil {
+		h.logger.Error("erro ao obter série de estatísticas", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("série de estatísticas retornada com sucesso",
+		"inicio", window.Start,
+		"fim", window.End,
+		"passo", step,
+		"pontos", len(points),
+	)
+
+	h.responder.Success(w, http.StatusOK, points)
+}
+
+// parseSeriesQuery extrai o intervalo e o passo da série. Por padrão a série
+// cobre todo o período de retenção até now.
+func parseSeriesQuery(r *http.Request, now time.Time, retention time.Duration) (utils.TimeWindow, time.Duration, error) {
+	values := r.URL.Query()
+
+	step := defaultSeriesStep
+	if values.Has("passo") {
+		parsed, err := utils.ParseDuration(values.Get("passo"))
+		if err != nil || parsed < time.Second || parsed%time.Second != 0 {
+			return utils.TimeWindow{}, 0, &queryError{
+				code:    "invalid_step",
+				message: "o passo deve ser um número inteiro de segundos maior que zero",
+			}
+		}
+		if parsed > retention {
+			return utils.TimeWindow{}, 0, &queryError{
+				code:    "invalid_step",
+				message: fmt.Sprintf("o passo não pode exceder a retenção de %s", retention),
+			}
+		}
+		step = parsed
+	}
+
+	end := now
+	if values.Has("fim") {
+		parsed, err := validator.ParseTimestamp(values.Get("fim"))
+		if err != nil {
+			return utils.TimeWindow{}, 0, &queryError{code: "invalid_range", message: "fim inválido"}
+		}
+		if parsed.After(now) {
+			return utils.TimeWindow{}, 0, &queryError{
+				code:    "invalid_range",
+				message: "fim não pode ser posterior ao momento atual",
+			}
+		}
+		end = parsed
+	}
+
+	start := now.Add(-retention)
+	if values.Has("inicio") {
+		parsed, err := validator.ParseTimestamp(values.Get("inicio"))
+		if err != nil {
+			return utils.TimeWindow{}, 0, &queryError{code: "invalid_range", message: "inicio inválido"}
+		}
+		start = parsed
+	}
+
+	if !start.Before(end) {
+		return utils.TimeWindow{}, 0, &queryError{
+			code:    "invalid_range",
+			message: "inicio deve ser anterior a fim",
+		}
+	}
+
+	if start.Before(now.Add(-retention)) {
+		return utils.TimeWindow{}, 0, &queryError{
+			code:    "range_out_of_retention",
+			message: fmt.Sprintf("inicio não pode ser anterior à retenção de %s", retention),
+		}
+	}
+
+	if end.Sub(start)/step >= maxSeriesPoints {
+		return utils.TimeWindow{}, 0, &queryError{
+			code:    "too_many_points",
+			message: fmt.Sprintf("a série não pode ter mais de %d pontos", maxSeriesPoints),
+		}
+	}
+
+	return utils.NewTimeWindow(start, end), step, nil
+}
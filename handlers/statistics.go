@@ -1,36 +1,165 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
 	"api-itau/pkg/logger"
+	"api-itau/pkg/utils"
+	"api-itau/pkg/validator"
 )
 
-// StatisticsResponse representa a resposta com as estatísticas das transações
+// StatisticsResponse representa a resposta com as estatísticas das transações.
+// Os campos de distribuição só são preenchidos quando solicitados.
 type StatisticsResponse struct {
-	Count int     `json:"count"`
-	Sum   float64 `json:"sum"`
-	Avg   float64 `json:"avg"`
-	Min   float64 `json:"min"`
-	Max   float64 `json:"max"`
+	Count       int                `json:"count"`
+	Sum         decimal.Decimal    `json:"sum"`
+	Avg         decimal.Decimal    `json:"avg"`
+	Min         decimal.Decimal    `json:"min"`
+	Max         decimal.Decimal    `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"`
+	StdDev      *float64           `json:"stdDev,omitempty"`
+	Variance    *float64           `json:"variance,omitempty"`
+	// Currency é a moeda consultada com o parâmetro moeda
+	Currency string `json:"moeda,omitempty"`
+	// Groups contém as estatísticas de cada grupo quando o parâmetro agrupar é informado
+	Groups map[string]*StatisticsResponse `json:"grupos,omitempty"`
+	// Consolidated é o total convertido para a moeda base, quando agrupado
+	// por moeda e há uma tabela de cotações configurada
+	Consolidated *ConsolidatedTotal `json:"consolidado,omitempty"`
+	// Balance é o saldo (créditos menos débitos), quando agrupado por tipo
+	Balance *decimal.Decimal `json:"saldo,omitempty"`
+	// Filters são os filtros aplicados, quando informados
+	Filters *AppliedFilter `json:"filtros,omitempty"`
 }
 
+// AppliedFilter descreve os filtros aplicados às estatísticas. Os filtros
+// percorrem as transações armazenadas; nos segundos em que elas foram
+// descartadas pelo limite de armazenamento, apenas os agregados foram
+// mantidos e as transações desses segundos não são consideradas.
+type AppliedFilter struct {
+	TransactionFilter
+	// Partial indica que a janela inclui segundos sem as transações
+	// armazenadas, ou seja, que o resultado pode estar incompleto
+	Partial bool `json:"parcial,omitempty"`
+	// Coverage é o intervalo da janela em que o filtro foi aplicado a todas
+	// as transações, quando o resultado é parcial
+	Coverage *FilterCoverage `json:"cobertura,omitempty"`
+}
+
+// FilterCoverage é o intervalo coberto por um filtro. Start é o primeiro
+// segundo considerado e End o fim da janela.
+type FilterCoverage struct {
+	Start time.Time `json:"inicio"`
+	End   time.Time `json:"fim"`
+}
+
+// ConsolidatedTotal representa o total de transações em várias moedas
+// convertido para a moeda base. As moedas sem cotação não são incluídas.
+type ConsolidatedTotal struct {
+	Currency    string          `json:"moeda"`
+	Count       int             `json:"count"`
+	Sum         decimal.Decimal `json:"sum"`
+	Avg         decimal.Decimal `json:"avg"`
+	Unconverted []string        `json:"semCotacao,omitempty"`
+}
+
+// StatisticsQuery representa os parâmetros opcionais do cálculo de estatísticas
+type StatisticsQuery struct {
+	// Percentiles lista os percentis (entre 0 e 100) a estimar. Quando vazio,
+	// as métricas de distribuição não são incluídas na resposta.
+	Percentiles []float64
+	// Window é a duração da janela consultada. Zero usa a janela padrão.
+	Window time.Duration
+	// Currency restringe as estatísticas a uma moeda. Vazio considera todas.
+	Currency string
+	// GroupBy é a dimensão pela qual as estatísticas são agrupadas. Vazio não agrupa.
+	GroupBy string
+	// Filter restringe as transações consideradas. Um filtro vazio usa os
+	// agregados pré-calculados, sem percorrer as transações.
+	Filter TransactionFilter
+}
+
+// Dimensões aceitas no parâmetro agrupar
+const (
+	// GroupByCurrency agrupa as estatísticas por moeda
+	GroupByCurrency = "moeda"
+	// GroupByType agrupa as estatísticas por tipo (débito ou crédito)
+	GroupByType = "tipo"
+)
+
+const (
+	// UntypedGroup é o grupo das transações sem tipo no agrupamento por tipo
+	UntypedGroup = "sem_tipo"
+	// OtherGroup agrega os valores de um rótulo que excedem a cardinalidade
+	// máxima (STATS_LABEL_MAX_VALUES)
+	OtherGroup = "_outros"
+)
+
 type StatisticsService interface {
-	GetStatistics() (*StatisticsResponse, error)
+	QueryStatistics(query StatisticsQuery) (*StatisticsResponse, error)
+	GetSeries(window utils.TimeWindow, step time.Duration) ([]SeriesPoint, error)
+	TopTransactions(n int, window time.Duration) ([]TopTransaction, error)
+	TopLimit() int
+	Histogram() (*HistogramResponse, error)
+	Throughput() (*ThroughputResponse, error)
+	Compare() (*ComparisonResponse, error)
+	GetPeriods(query PeriodQuery) ([]SeriesPoint, error)
+	PeriodTimezones() []string
+	PeriodRetention() time.Duration
+	Retention() time.Duration
+	// Now retorna o momento atual no relógio usado pelo serviço, referência
+	// dos intervalos padrão das consultas
+	Now() time.Time
+}
+
+// maxPercentiles limita a quantidade de percentis por requisição
+const maxPercentiles = 20
+
+// defaultPercentiles são os percentis usados quando o parâmetro é informado vazio
+var defaultPercentiles = []float64{50, 90, 95, 99}
+
+// PercentileKey retorna a chave usada na resposta para um percentil (ex.: "p99")
+func PercentileKey(p float64) string {
+	return "p" + strconv.FormatFloat(p, 'f', -1, 64)
 }
 
 // StatisticsHandler encapsula a lógica de manipulação de requisições de estatísticas
 type StatisticsHandler struct {
-	service StatisticsService
-	logger  logger.Logger
+	service   StatisticsService
+	logger    logger.Logger
+	responder Responder
+}
+
+// StatisticsHandlerOption configura recursos opcionais do StatisticsHandler
+type StatisticsHandlerOption func(*StatisticsHandler)
+
+// WithStatisticsResponder define o perfil das respostas e o formato dos
+// erros do handler
+func WithStatisticsResponder(responder Responder) StatisticsHandlerOption {
+	return func(h *StatisticsHandler) {
+		h.responder = responder
+	}
 }
 
 // NewStatisticsHandler cria uma nova instância do StatisticsHandler
-func NewStatisticsHandler(service StatisticsService, logger logger.Logger) *StatisticsHandler {
-	return &StatisticsHandler{
+func NewStatisticsHandler(service StatisticsService, logger logger.Logger, opts ...StatisticsHandlerOption) *StatisticsHandler {
+	h := &StatisticsHandler{
 		service: service,
 		logger:  logger,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // ServeHTTP implementa a interface http.Handler
@@ -38,26 +167,27 @@ func (h *StatisticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Verifica se o método é GET
 	if r.Method != http.MethodGet {
 		h.logger.Error("método não permitido", "método", r.Method)
-		RespondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Método não permitido")
+		h.responder.Error(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Método não permitido")
 		return
 	}
 
-	stats, err := h.service.GetStatistics()
+	query, err := parseStatisticsQuery(r, h.service.Retention())
+	if err != nil {
+		h.logger.Error("parâmetros de estatísticas inválidos", "erro", err)
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	stats, err := h.service.QueryStatistics(query)
 	if err != nil {
 		h.logger.Error("erro ao obter estatísticas", "erro", err)
-		RespondWithError(w, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
 		return
 	}
 
 	// Garante que temos um objeto de estatísticas válido
 	if stats == nil {
-		stats = &StatisticsResponse{
-			Count: 0,
-			Sum:   0,
-			Avg:   0,
-			Min:   0,
-			Max:   0,
-		}
+		stats = &StatisticsResponse{}
 	}
 
 	h.logger.Info("estatísticas retornadas com sucesso",
@@ -68,14 +198,129 @@ func (h *StatisticsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"max", stats.Max,
 	)
 
-	// Cria uma resposta formatada
-	response := APIResponse{
-		Success: true,
-		Data:    stats,
+	h.responder.Success(w, http.StatusOK, stats)
+}
+
+// queryError representa um parâmetro de consulta inválido
+type queryError struct {
+	code    string
+	message string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+// invalidQuery envia um erro 400 para um parâmetro de consulta inválido
+func (res Responder) invalidQuery(w http.ResponseWriter, r *http.Request, err error) {
+	var qerr *queryError
+	if errors.As(err, &qerr) {
+		res.Error(w, r, http.StatusBadRequest, qerr.code, qerr.message)
+		return
+	}
+	res.Error(w, r, http.StatusBadRequest, "invalid_query", err.Error())
+}
+
+// parseStatisticsQuery extrai os parâmetros opcionais da query string.
+// A janela solicitada não pode exceder o período de retenção informado.
+func parseStatisticsQuery(r *http.Request, retention time.Duration) (StatisticsQuery, error) {
+	var query StatisticsQuery
+
+	values := r.URL.Query()
+	if values.Has("percentiles") {
+		percentiles, err := parsePercentiles(values.Get("percentiles"))
+		if err != nil {
+			return query, &queryError{code: "invalid_percentiles", message: err.Error()}
+		}
+		query.Percentiles = percentiles
+	}
+
+	if values.Has("janela") {
+		window, err := parseWindow(values.Get("janela"), retention)
+		if err != nil {
+			return query, err
+		}
+		query.Window = window
+	}
+
+	if values.Has("moeda") {
+		code, err := currency.Normalize(values.Get("moeda"))
+		if err != nil {
+			return query, &queryError{code: "invalid_currency", message: err.Error()}
+		}
+		query.Currency = code
+	}
+
+	if values.Has("agrupar") {
+		groupBy := values.Get("agrupar")
+		if groupBy != GroupByCurrency && groupBy != GroupByType && !validator.IsLabelKey(groupBy) {
+			return query, &queryError{
+				code:    "invalid_group",
+				message: fmt.Sprintf("agrupar deve ser %s, %s ou o nome de um rótulo", GroupByCurrency, GroupByType),
+			}
+		}
+		query.GroupBy = groupBy
+	}
+
+	filter, err := parseFilter(values)
+	if err != nil {
+		return query, err
+	}
+	query.Filter = filter
+
+	return query, nil
+}
+
+// parseWindow interpreta a duração de uma janela ("300s" ou "PT5M"), que deve
+// ser um número inteiro de segundos entre 1 segundo e a retenção informada
+func parseWindow(raw string, retention time.Duration) (time.Duration, error) {
+	window, err := utils.ParseDuration(raw)
+	if err != nil {
+		return 0, &queryError{
+			code:    "invalid_window",
+			message: fmt.Sprintf("janela inválida: %q", raw),
+		}
+	}
+
+	if window < time.Second || window%time.Second != 0 {
+		return 0, &queryError{
+			code:    "invalid_window",
+			message: "a janela deve ser um número inteiro de segundos maior que zero",
+		}
+	}
+
+	if window > retention {
+		return 0, &queryError{
+			code:    "window_too_large",
+			message: fmt.Sprintf("a janela não pode exceder a retenção de %s", retention),
+		}
+	}
+
+	return window, nil
+}
+
+// parsePercentiles interpreta uma lista de percentis separados por vírgula.
+// Uma lista vazia resulta nos percentis padrão.
+func parsePercentiles(raw string) ([]float64, error) {
+	if strings.TrimSpace(raw) == "" {
+		return defaultPercentiles, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	if len(parts) > maxPercentiles {
+		return nil, fmt.Errorf("no máximo %d percentis podem ser solicitados", maxPercentiles)
+	}
+
+	percentiles := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || !(p > 0 && p <= 100) {
+			return nil, fmt.Errorf("percentil inválido: %q", part)
+		}
+		percentiles = append(percentiles, p)
 	}
 
-	// Envia a resposta
-	RespondWithJSON(w, http.StatusOK, response)
+	return percentiles, nil
 }
 
 type Logger interface {
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"api-itau/pkg/logger"
+)
+
+// StatisticsSubscriber define o contrato para a inscrição em atualizações de estatísticas
+type StatisticsSubscriber interface {
+	Subscribe() (<-chan StatisticsResponse, func())
+}
+
+// StatisticsStreamHandler envia as estatísticas via Server-Sent Events
+type StatisticsStreamHandler struct {
+	subscriber StatisticsSubscriber
+	logger     logger.Logger
+}
+
+// NewStatisticsStreamHandler cria uma nova instância do StatisticsStreamHandler
+func NewStatisticsStreamHandler(subscriber StatisticsSubscriber, logger logger.Logger) *StatisticsStreamHandler {
+	return &StatisticsStreamHandler{
+		subscriber: subscriber,
+		logger:     logger,
+	}
+}
+
+// ServeHTTP implementa a interface http.Handler. A conexão permanece aberta
+// até o cliente desconectar ou o broadcaster ser encerrado no shutdown.
+func (h *StatisticsStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// O stream não deve ser interrompido pelo WriteTimeout do servidor
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Error("não foi possível remover o deadline de escrita", "erro", err)
+	}
+
+	updates, unsubscribe := h.subscriber.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("streaming não suportado", "erro", err)
+		return
+	}
+
+	h.logger.Info("cliente inscrito no stream de estatísticas", "remote_addr", r.RemoteAddr)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			h.logger.Info("cliente desconectado do stream de estatísticas", "remote_addr", r.RemoteAddr)
+			return
+
+		case stats, ok := <-updates:
+			if !ok {
+				h.logger.Info("stream de estatísticas encerrado", "remote_addr", r.RemoteAddr)
+				return
+			}
+
+			data, err := json.Marshal(stats)
+			if err != nil {
+				h.logger.Error("erro ao serializar estatísticas", "erro", err)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: estatistica\ndata: %s\n\n", data); err != nil {
+				h.logger.Error("erro ao enviar evento", "erro", err)
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				h.logger.Error("erro ao enviar evento", "erro", err)
+				return
+			}
+		}
+	}
+}
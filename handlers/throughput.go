@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"api-itau/pkg/decimal"
+)
+
+// RateAverages são as médias móveis exponenciais de uma taxa em 1, 5 e 15
+// minutos, como no load average
+type RateAverages struct {
+	M1  float64 `json:"m1"`
+	M5  float64 `json:"m5"`
+	M15 float64 `json:"m15"`
+}
+
+// ThroughputResponse representa a velocidade de chegada das transações
+type ThroughputResponse struct {
+	// TPS é a quantidade média de transações por segundo na janela padrão
+	TPS float64 `json:"tps"`
+	// ValuePerSecond é a soma dos valores da janela padrão por segundo
+	ValuePerSecond decimal.Decimal `json:"valorPorSegundo"`
+	// TPSAverages são as médias móveis das transações recebidas por segundo
+	TPSAverages RateAverages `json:"tpsMedia"`
+	// ValueAverages são as médias móveis do valor recebido por segundo
+	ValueAverages RateAverages `json:"valorPorSegundoMedia"`
+}
+
+// HandleThroughput processa requisições GET /estatistica/taxa, retornando
+// a taxa atual de transações e de valor por segundo e suas médias móveis
+func (h *StatisticsHandler) HandleThroughput(w http.ResponseWriter, r *http.Request) {
+	throughput, err := h.service.Throughput()
+	if err != nil {
+		h.logger.Error("erro ao obter taxa de transações", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("taxa de transações retornada com sucesso",
+		"tps", throughput.TPS,
+		"valorPorSegundo", throughput.ValuePerSecond,
+	)
+
+	h.responder.Success(w, http.StatusOK, throughput)
+}
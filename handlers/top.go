@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api-itau/pkg/decimal"
+)
+
+// defaultTopN é a quantidade de transações quando n não é informado
+const defaultTopN = 10
+
+// TopTransaction representa uma das maiores transações da janela
+type TopTransaction struct {
+	ID        string            `json:"id,omitempty"`
+	Value     decimal.Decimal   `json:"valor"`
+	Currency  string            `json:"moeda"`
+	Timestamp time.Time         `json:"dataHora"`
+	Labels    map[string]string `json:"rotulos,omitempty"`
+}
+
+// HandleTop processa requisições GET /estatistica/top, retornando as n
+// transações de maior valor da janela em ordem decrescente de valor
+func (h *StatisticsHandler) HandleTop(w http.ResponseWriter, r *http.Request) {
+	n, window, err := parseTopQuery(r, h.service.TopLimit(), h.service.Retention())
+	if err != nil {
+		h.logger.Error("parâmetros do ranking inválidos", "erro", err)
+		h.responder.invalidQuery(w, r, err)
+		return
+	}
+
+	transactions, err := h.service.TopTransactions(n, window)
+	if err != nil {
+		h.logger.Error("erro ao obter maiores transações", "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro interno do servidor")
+		return
+	}
+
+	h.logger.Info("maiores transações retornadas com sucesso",
+		"n", n,
+		"transacoes", len(transactions),
+	)
+
+	h.responder.Success(w, http.StatusOK, transactions)
+}
+
+// parseTopQuery extrai a quantidade de transações, entre 1 e limit, e a
+// janela opcional do ranking
+func parseTopQuery(r *http.Request, limit int, retention time.Duration) (int, time.Duration, error) {
+	values := r.URL.Query()
+
+	n := min(defaultTopN, limit)
+	if values.Has("n") {
+		parsed, err := strconv.Atoi(values.Get("n"))
+		if err != nil || parsed < 1 || parsed > limit {
+			return 0, 0, &queryError{
+				code:    "invalid_top",
+				message: fmt.Sprintf("n deve ser um número inteiro entre 1 e %d", limit),
+			}
+		}
+		n = parsed
+	}
+
+	var window time.Duration
+	if values.Has("janela") {
+		parsed, err := parseWindow(values.Get("janela"), retention)
+		if err != nil {
+			return 0, 0, err
+		}
+		window = parsed
+	}
+
+	return n, window, nil
+}
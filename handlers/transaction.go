@@ -2,56 +2,112 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"time"
 
+	"api-itau/internal/idempotency"
 	"api-itau/internal/models"
+	"api-itau/pkg/decimal"
 	"api-itau/pkg/logger"
+	"api-itau/pkg/validator"
 )
 
-// TransactionRequest representa o payload da requisição de transação
+// TransactionRequest representa o payload da requisição de transação.
+// Os campos são ponteiros para distinguir um campo ausente (ou null) de um
+// valor zero.
 type TransactionRequest struct {
-	Value     float64   `json:"valor"`
-	Timestamp time.Time `json:"dataHora"`
+	Value     *decimal.Decimal `json:"valor"`
+	Timestamp *time.Time       `json:"dataHora"`
+	// Currency é o código ISO 4217 da moeda. Vazio usa a moeda padrão (BRL).
+	Currency string `json:"moeda,omitempty"`
+	// Type é débito ou crédito. Vazio indica que o tipo não foi informado.
+	Type        string `json:"tipo,omitempty"`
+	Description string `json:"descricao,omitempty"`
+	// Labels são rótulos livres usados no agrupamento das estatísticas
+	Labels map[string]string `json:"rotulos,omitempty"`
 }
 
 // TransactionResponse representa a resposta de uma transação bem-sucedida
 type TransactionResponse struct {
-	Value     float64   `json:"valor"`
-	Timestamp time.Time `json:"dataHora"`
+	ID          string            `json:"id"`
+	Value       decimal.Decimal   `json:"valor"`
+	Currency    string            `json:"moeda"`
+	Timestamp   time.Time         `json:"dataHora"`
+	Type        string            `json:"tipo,omitempty"`
+	Description string            `json:"descricao,omitempty"`
+	Labels      map[string]string `json:"rotulos,omitempty"`
 }
 
 // TransactionService define o contrato para o serviço de transações
 type TransactionService interface {
-	AddTransaction(models.Transaction) error
+	// AddTransaction retorna se a transação foi armazenada e pode ser
+	// consultada pelo id. Transações anteriores à retenção ou além do
+	// limite de armazenamento compõem apenas as estatísticas.
+	AddTransaction(models.Transaction) (bool, error)
+	AddTransactions([]models.Transaction) error
+	GetTransaction(id string) (models.Transaction, error)
+	EachTransaction(fn func(models.Transaction) error) error
 	DeleteTransactions() error
 }
 
 // TransactionHandler encapsula a lógica de manipulação de requisições de transações
 type TransactionHandler struct {
-	service TransactionService
-	logger  logger.Logger
+	service       TransactionService
+	logger        logger.Logger
+	idempotency   *idempotency.Store
+	batchMaxItems int
+
+	streamMaxLineBytes int
+	streamMaxLines     int
+	csvDelimiter       rune
+	validator          *validator.TransactionValidator
+	responder          Responder
+}
+
+// TransactionHandlerOption configura recursos opcionais do TransactionHandler
+type TransactionHandlerOption func(*TransactionHandler)
+
+// WithResponder define o perfil das respostas e o formato dos erros do handler
+func WithResponder(responder Responder) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.responder = responder
+	}
 }
 
 // NewTransactionHandler cria uma nova instância do TransactionHandler
-func NewTransactionHandler(service TransactionService, logger logger.Logger) *TransactionHandler {
-	return &TransactionHandler{
-		service: service,
-		logger:  logger,
+func NewTransactionHandler(service TransactionService, logger logger.Logger, opts ...TransactionHandlerOption) *TransactionHandler {
+	h := &TransactionHandler{
+		service:       service,
+		logger:        logger,
+		batchMaxItems: defaultBatchMaxItems,
+
+		streamMaxLineBytes: defaultStreamMaxLineBytes,
+		streamMaxLines:     defaultStreamMaxLines,
+		csvDelimiter:       defaultCSVDelimiter,
+		validator:          validator.NewTransactionValidator(defaultMaxTransactionValue, defaultMaxTransactionAge),
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
 
 // ServeHTTP implementa a interface http.Handler
 func (h *TransactionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
+	case http.MethodGet:
+		h.handleGet(w, r)
 	case http.MethodPost:
 		h.handlePost(w, r)
 	case http.MethodDelete:
 		h.handleDelete(w, r)
 	default:
 		h.logger.Error("método não permitido", "método", r.Method)
-		RespondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Método não permitido")
+		h.responder.Error(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Método não permitido")
 	}
 }
 
@@ -61,56 +117,168 @@ func (h *TransactionHandler) handlePost(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20)) // 1 MB
 	if err != nil {
 		h.logger.Error("erro ao ler corpo da requisição", "erro", err)
-		RespondWithError(w, http.StatusBadRequest, "invalid_request", "Erro ao ler requisição")
+		h.responder.Error(w, r, http.StatusBadRequest, "invalid_request", "Erro ao ler requisição")
 		return
 	}
 	defer r.Body.Close()
 
-	var req TransactionRequest
-	if err := json.Unmarshal(body, &req); err != nil {
-		h.logger.Error("erro ao decodificar JSON", "erro", err)
-		RespondWithError(w, http.StatusBadRequest, "invalid_json", "JSON inválido")
+	if key := r.Header.Get(IdempotencyKeyHeader); key != "" && h.idempotency != nil {
+		h.handleIdempotentPost(w, r, key, body)
 		return
 	}
 
-	// Cria e valida a transação
-	transaction, err := models.NewTransaction(req.Value, req.Timestamp)
-	if err != nil {
-		h.logger.Error("transação inválida", "erro", err)
-		RespondWithError(w, http.StatusUnprocessableEntity, "invalid_transaction", "Transação inválida")
+	h.createTransaction(w, r, body)
+}
+
+// createTransaction decodifica, valida e registra uma transação
+func (h *TransactionHandler) createTransaction(w http.ResponseWriter, r *http.Request, body []byte) {
+	transaction, rejection := h.parseTransaction(body)
+	if rejection != nil {
+		h.logger.Error("transação rejeitada", "erro", rejection.err)
+		h.responder.ErrorDetails(w, r, rejection.status, rejection.code, rejection.message, rejection.details)
 		return
 	}
 
 	// Adiciona a transação através do serviço
-	if err := h.service.AddTransaction(*transaction); err != nil {
+	stored, err := h.service.AddTransaction(*transaction)
+	if err != nil {
 		h.logger.Error("erro ao adicionar transação", "erro", err)
-		RespondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao processar transação")
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro ao processar transação")
 		return
 	}
 
 	h.logger.Info("transação criada com sucesso",
+		"id", transaction.ID,
 		"valor", transaction.Value,
 		"dataHora", transaction.Timestamp,
+		"armazenada", stored,
 	)
 
-	response := TransactionResponse{
-		Value:     transaction.Value,
-		Timestamp: transaction.Timestamp,
+	// Location só é informado quando a transação pode ser consultada pelo id
+	if stored {
+		w.Header().Set("Location", "/transacao/"+transaction.ID)
+	}
+	h.responder.Ack(w, http.StatusCreated, newTransactionResponse(*transaction))
+}
+
+// rejection descreve o motivo pelo qual uma transação recebida foi rejeitada
+type rejection struct {
+	status  int
+	code    string
+	message string
+	details []FieldViolation
+	err     error
+}
+
+// parseTransaction decodifica e valida uma transação em JSON. É usada por
+// todos os pontos de entrada de transações, para que as mesmas regras se
+// apliquem à inclusão individual e às ingestões em massa.
+func (h *TransactionHandler) parseTransaction(data []byte) (*models.Transaction, *rejection) {
+	var req TransactionRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, &rejection{
+			status:  http.StatusBadRequest,
+			code:    "invalid_json",
+			message: "JSON inválido",
+			details: decodeViolations(err),
+			err:     err,
+		}
 	}
 
-	RespondWithSuccess(w, http.StatusCreated, response)
+	return h.newTransaction(req)
+}
+
+// newTransaction valida os campos recebidos com o TransactionValidator e cria
+// a transação. Campos nil são tratados como ausentes.
+func (h *TransactionHandler) newTransaction(req TransactionRequest) (*models.Transaction, *rejection) {
+	err := errors.Join(
+		h.validator.Validate(req.Value, req.Timestamp),
+		h.validator.ValidateCurrency(req.Currency),
+		validateType(req.Type),
+		h.validator.ValidateDescription(req.Description),
+		h.validator.ValidateLabels(req.Labels),
+	)
+	if err != nil {
+		return nil, &rejection{
+			status:  http.StatusUnprocessableEntity,
+			code:    "invalid_transaction",
+			message: "Transação inválida",
+			details: fieldViolations(err, req),
+			err:     err,
+		}
+	}
+
+	// Cria e valida a transação
+	transaction, err := models.NewTransaction(*req.Value, *req.Timestamp,
+		models.WithCurrency(req.Currency),
+		models.WithType(req.Type),
+		models.WithDescription(req.Description),
+		models.WithLabels(req.Labels),
+	)
+	if err != nil {
+		return nil, &rejection{
+			status:  http.StatusUnprocessableEntity,
+			code:    "invalid_transaction",
+			message: "Transação inválida",
+			details: fieldViolations(err, req),
+			err:     err,
+		}
+	}
+
+	return transaction, nil
+}
+
+// validateType verifica o tipo com models.ParseType. Um tipo vazio é válido
+// e indica que o tipo não foi informado.
+func validateType(kind string) error {
+	if kind == "" {
+		return nil
+	}
+	_, err := models.ParseType(kind)
+	return err
+}
+
+// handleGet processa requisições GET /transacao/{id}
+func (h *TransactionHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	transaction, err := h.service.GetTransaction(id)
+	if errors.Is(err, models.ErrTransactionNotFound) {
+		h.responder.Error(w, r, http.StatusNotFound, "transaction_not_found", "Transação não encontrada")
+		return
+	}
+	if err != nil {
+		h.logger.Error("erro ao obter transação", "id", id, "erro", err)
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro ao obter transação")
+		return
+	}
+
+	h.responder.Success(w, http.StatusOK, newTransactionResponse(transaction))
+}
+
+// newTransactionResponse converte uma transação na resposta da API
+func newTransactionResponse(t models.Transaction) TransactionResponse {
+	return TransactionResponse{
+		ID:          t.ID,
+		Value:       t.Value,
+		Currency:    t.Currency,
+		Timestamp:   t.Timestamp,
+		Type:        t.Type,
+		Description: t.Description,
+		Labels:      t.Labels,
+	}
 }
 
 // handleDelete processa requisições DELETE para remover todas as transações
-func (h *TransactionHandler) handleDelete(w http.ResponseWriter, _ *http.Request) {
+func (h *TransactionHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 	if err := h.service.DeleteTransactions(); err != nil {
 		h.logger.Error("erro ao deletar transações", "erro", err)
-		RespondWithError(w, http.StatusInternalServerError, "internal_error", "Erro ao deletar transações")
+		h.responder.Error(w, r, http.StatusInternalServerError, "internal_error", "Erro ao deletar transações")
 		return
 	}
 
 	h.logger.Info("todas as transações foram deletadas")
-	RespondWithSuccess(w, http.StatusOK, map[string]string{
+	h.responder.Ack(w, http.StatusOK, map[string]string{
 		"message": "Todas as transações foram deletadas com sucesso",
 	})
 }
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"api-itau/internal/models"
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/validator"
+)
+
+// defaultMaxTransactionValue é o maior valor de transação aceito por padrão
+var defaultMaxTransactionValue = decimal.NewFromInt(1000000000)
+
+// defaultMaxTransactionAge é a idade máxima padrão da data de uma transação
+const defaultMaxTransactionAge = 5 * 365 * 24 * time.Hour
+
+// Campos de uma transação referenciados nas violações
+const (
+	fieldValue       = "valor"
+	fieldTimestamp   = "dataHora"
+	fieldCurrency    = "moeda"
+	fieldType        = "tipo"
+	fieldDescription = "descricao"
+	fieldLabels      = "rotulos"
+)
+
+// FieldViolation descreve uma regra violada por um campo da requisição
+type FieldViolation struct {
+	Field   string      `json:"campo,omitempty"`
+	Rule    string      `json:"regra"`
+	Message string      `json:"mensagem"`
+	Value   interface{} `json:"valorRejeitado,omitempty"`
+}
+
+// fieldRule associa um erro sentinela ao campo e à regra que ele representa
+type fieldRule struct {
+	err   error
+	field string
+	rule  string
+}
+
+// fieldRules mapeia os erros de validação dos modelos e do validador para
+// o campo e a regra reportados ao cliente
+var fieldRules = []fieldRule{
+	{validator.ErrValueRequired, fieldValue, "obrigatorio"},
+	{models.ErrNegativeValue, fieldValue, "nao_negativo"},
+	{validator.ErrValueTooLarge, fieldValue, "valor_maximo"},
+	{validator.ErrTimestampRequired, fieldTimestamp, "obrigatorio"},
+	{models.ErrFutureTimestamp, fieldTimestamp, "nao_futuro"},
+	{validator.ErrTimestampTooOld, fieldTimestamp, "idade_maxima"},
+	{validator.ErrInvalidTimestamp, fieldTimestamp, "formato"},
+	{currency.ErrInvalidCode, fieldCurrency, "formato"},
+	{models.ErrInvalidType, fieldType, "enum"},
+	{validator.ErrDescriptionLength, fieldDescription, "tamanho_maximo"},
+	{validator.ErrDescriptionChars, fieldDescription, "caracteres"},
+	{validator.ErrTooManyLabels, fieldLabels, "quantidade_maxima"},
+	{validator.ErrInvalidLabelKey, fieldLabels, "nome"},
+	{validator.ErrInvalidLabelValue, fieldLabels, "valor"},
+}
+
+// WithValidator define o validador usado em todos os pontos de entrada de transações
+func WithValidator(v *validator.TransactionValidator) TransactionHandlerOption {
+	return func(h *TransactionHandler) {
+		h.validator = v
+	}
+}
+
+// fieldViolations converte os erros de validação, inclusive os agrupados com
+// errors.Join, em violações por campo. Os campos de req são reportados como
+// valor rejeitado do campo correspondente; textos longos são truncados no
+// tamanho máximo do campo.
+func fieldViolations(err error, req TransactionRequest) []FieldViolation {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var violations []FieldViolation
+		for _, e := range joined.Unwrap() {
+			violations = append(violations, fieldViolations(e, req)...)
+		}
+		return violations
+	}
+
+	violation := FieldViolation{Rule: "invalido", Message: err.Error()}
+	for _, r := range fieldRules {
+		if errors.Is(err, r.err) {
+			violation.Field = r.field
+			violation.Rule = r.rule
+			break
+		}
+	}
+
+	switch {
+	case violation.Field == fieldValue && req.Value != nil:
+		violation.Value = *req.Value
+	case violation.Field == fieldTimestamp && req.Timestamp != nil:
+		violation.Value = *req.Timestamp
+	case violation.Field == fieldCurrency && req.Currency != "":
+		violation.Value = req.Currency
+	case violation.Field == fieldType && req.Type != "":
+		violation.Value = req.Type
+	case violation.Field == fieldDescription && req.Description != "":
+		violation.Value = truncateRejected(req.Description, validator.MaxDescriptionLength)
+	case violation.Field == fieldLabels:
+		violation.Value = rejectedLabels(err, req.Labels)
+	}
+
+	return []FieldViolation{violation}
+}
+
+// rejectedLabels retorna o rótulo que violou a regra, no formato
+// {nome: valor}, ou a quantidade de rótulos quando ela excede o limite
+func rejectedLabels(err error, labels map[string]string) interface{} {
+	var labelErr *validator.LabelError
+	if !errors.As(err, &labelErr) {
+		return len(labels)
+	}
+	key := truncateRejected(labelErr.Key, validator.MaxLabelKeyLength)
+	return map[string]string{key: truncateRejected(labels[labelErr.Key], validator.MaxLabelValueLength)}
+}
+
+// truncateRejected limita um valor rejeitado a max caracteres, indicando o
+// corte com reticências
+func truncateRejected(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max]) + "…"
+}
+
+// decodeViolations descreve o campo de um JSON que não pôde ser decodificado,
+// quando o erro permite identificá-lo
+func decodeViolations(err error) []FieldViolation {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		// Erros retornados por decimal.Decimal.UnmarshalJSON não informam o
+		// campo, e valor é o único campo decimal da transação
+		if field == "" && typeErr.Type == reflect.TypeOf(decimal.Decimal{}) {
+			field = fieldValue
+		}
+		if field != "" {
+			return []FieldViolation{{
+				Field:   field,
+				Rule:    "tipo",
+				Message: fmt.Sprintf("campo '%s' possui tipo inválido (recebido %s)", field, typeErr.Value),
+			}}
+		}
+	}
+
+	if errors.Is(err, decimal.ErrInvalidNumber) || errors.Is(err, decimal.ErrOutOfRange) {
+		return []FieldViolation{{
+			Field:   fieldValue,
+			Rule:    "formato",
+			Message: err.Error(),
+		}}
+	}
+
+	var timeErr *time.ParseError
+	if errors.As(err, &timeErr) {
+		return []FieldViolation{{
+			Field:   fieldTimestamp,
+			Rule:    "formato",
+			Message: validator.ErrInvalidTimestamp.Error(),
+			Value:   timeErr.Value,
+		}}
+	}
+
+	return nil
+}
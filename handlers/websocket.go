@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"api-itau/internal/models"
+	"api-itau/internal/websocket"
+	"api-itau/pkg/logger"
+)
+
+// Tópicos disponíveis no feed WebSocket
+const (
+	TopicTransactions = "transacoes"
+	TopicStatistics   = "estatisticas"
+)
+
+const (
+	// wsMaxMessageSize limita o tamanho dos comandos enviados pelos clientes
+	wsMaxMessageSize = 4096
+	// wsSendBuffer é a quantidade de mensagens pendentes por cliente antes
+	// que ele seja considerado lento e desconectado
+	wsSendBuffer = 256
+	// wsWriteTimeout é o prazo para enviar uma mensagem a um cliente
+	wsWriteTimeout = 5 * time.Second
+	// wsPingInterval é o intervalo entre pings para manter a conexão ativa
+	wsPingInterval = 30 * time.Second
+)
+
+// wsCommand representa um comando enviado pelo cliente
+type wsCommand struct {
+	Action string   `json:"acao"`
+	Topics []string `json:"topicos"`
+}
+
+// wsMessage representa uma mensagem enviada ao cliente
+type wsMessage struct {
+	Type   string      `json:"tipo"`
+	Topic  string      `json:"topico,omitempty"`
+	Data   interface{} `json:"dados,omitempty"`
+	Topics []string    `json:"topicos,omitempty"`
+	Error  string      `json:"erro,omitempty"`
+}
+
+// WebSocketHandler mantém o feed WebSocket de transações aceitas e de
+// estatísticas. Cada cliente possui uma fila própria: a publicação nunca
+// bloqueia, e um cliente cuja fila enche é desconectado.
+type WebSocketHandler struct {
+	stats   StatisticsSubscriber
+	logger  logger.Logger
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+	closed  bool
+	// responder envia o erro de um handshake inválido
+	responder Responder
+}
+
+// WebSocketHandlerOption configura recursos opcionais do WebSocketHandler
+type WebSocketHandlerOption func(*WebSocketHandler)
+
+// WithWebSocketResponder define o perfil das respostas e o formato dos
+// erros do handshake
+func WithWebSocketResponder(responder Responder) WebSocketHandlerOption {
+	return func(h *WebSocketHandler) {
+		h.responder = responder
+	}
+}
+
+// NewWebSocketHandler cria uma nova instância do WebSocketHandler
+func NewWebSocketHandler(stats StatisticsSubscriber, logger logger.Logger, opts ...WebSocketHandlerOption) *WebSocketHandler {
+	h := &WebSocketHandler{
+		stats:   stats,
+		logger:  logger,
+		clients: make(map[*wsClient]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// ServeHTTP implementa a interface http.Handler, realizando o upgrade da
+// conexão. Os tópicos iniciais podem ser informados em ?topicos=.
+func (h *WebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := websocket.Upgrade(w, r, wsMaxMessageSize)
+	if err != nil {
+		h.logger.Error("erro no upgrade para websocket", "erro", err)
+		if errors.Is(err, websocket.ErrBadHandshake) {
+			h.responder.Error(w, r, http.StatusBadRequest, "invalid_handshake", "Requisição de upgrade WebSocket inválida")
+		}
+		return
+	}
+
+	client := &wsClient{
+		handler: h,
+		conn:    conn,
+		send:    make(chan []byte, wsSendBuffer),
+		done:    make(chan struct{}),
+	}
+
+	if !h.register(client) {
+		conn.Close(websocket.CloseGoingAway, "servidor em desligamento")
+		return
+	}
+
+	h.logger.Info("cliente websocket conectado", "remote_addr", conn.RemoteAddr())
+
+	go client.writeLoop()
+
+	if raw := r.URL.Query().Get("topicos"); raw != "" {
+		client.handleCommand(wsCommand{Action: "inscrever", Topics: strings.Split(raw, ",")})
+	}
+
+	client.readLoop()
+}
+
+// PublishTransaction envia uma transação aceita aos clientes inscritos em transacoes
+func (h *WebSocketHandler) PublishTransaction(t models.Transaction) {
+	data, err := json.Marshal(wsMessage{Type: "evento", Topic: TopicTransactions, Data: t})
+	if err != nil {
+		h.logger.Error("erro ao serializar transação para websocket", "erro", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		if client.subscribedToTransactions() {
+			client.enqueue(data)
+		}
+	}
+}
+
+// Close desconecta todos os clientes. Deve ser chamado no shutdown, pois
+// conexões WebSocket não são acompanhadas pelo http.Server.
+func (h *WebSocketHandler) Close() {
+	h.mu.Lock()
+	h.closed = true
+	clients := make([]*wsClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.close(websocket.CloseGoingAway, "servidor em desligamento")
+	}
+
+	h.logger.Info("clientes websocket desconectados", "total", len(clients))
+}
+
+// register adiciona um cliente, retornando false se o handler já foi encerrado
+func (h *WebSocketHandler) register(client *wsClient) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return false
+	}
+	h.clients[client] = struct{}{}
+	return true
+}
+
+// unregister remove um cliente
+func (h *WebSocketHandler) unregister(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, client)
+}
+
+// wsClient representa uma conexão WebSocket e suas inscrições
+type wsClient struct {
+	handler          *WebSocketHandler
+	conn             *websocket.Conn
+	send             chan []byte
+	done             chan struct{}
+	closeOnce        sync.Once
+	mu               sync.Mutex
+	transactions     bool
+	unsubscribeStats func()
+}
+
+// enqueue adiciona uma mensagem à fila sem bloquear. Se a fila estiver cheia
+// o cliente é desconectado, para que não atrase os demais.
+func (c *wsClient) enqueue(data []byte) {
+	select {
+	case <-c.done:
+	case c.send <- data:
+	default:
+		c.handler.logger.Error("cliente websocket lento desconectado", "remote_addr", c.conn.RemoteAddr())
+		go c.close(websocket.CloseTryAgainLater, "cliente lento")
+	}
+}
+
+// subscribedToTransactions informa se o cliente está inscrito em transacoes
+func (c *wsClient) subscribedToTransactions() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transactions
+}
+
+// topics retorna os tópicos em que o cliente está inscrito
+func (c *wsClient) topics() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	topics := make([]string, 0, 2)
+	if c.transactions {
+		topics = append(topics, TopicTransactions)
+	}
+	if c.unsubscribeStats != nil {
+		topics = append(topics, TopicStatistics)
+	}
+	return topics
+}
+
+// readLoop processa os comandos do cliente até a conexão ser encerrada
+func (c *wsClient) readLoop() {
+	defer c.close(websocket.CloseNormal, "")
+
+	for {
+		opcode, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.handler.logger.Info("cliente websocket desconectado",
+				"remote_addr", c.conn.RemoteAddr(),
+				"motivo", err,
+			)
+			return
+		}
+
+		if opcode != websocket.OpText {
+			c.sendError("apenas mensagens de texto são aceitas")
+			continue
+		}
+
+		var cmd wsCommand
+		if err := json.Unmarshal(data, &cmd); err != nil {
+			c.sendError("comando JSON inválido")
+			continue
+		}
+
+		c.handleCommand(cmd)
+	}
+}
+
+// handleCommand aplica um comando de inscrição ou cancelamento
+func (c *wsClient) handleCommand(cmd wsCommand) {
+	var subscribe bool
+	switch cmd.Action {
+	case "inscrever":
+		subscribe = true
+	case "cancelar":
+		subscribe = false
+	default:
+		c.sendError("ação inválida: use inscrever ou cancelar")
+		return
+	}
+
+	for _, topic := range cmd.Topics {
+		switch strings.TrimSpace(topic) {
+		case TopicTransactions:
+			c.mu.Lock()
+			c.transactions = subscribe
+			c.mu.Unlock()
+		case TopicStatistics:
+			if subscribe {
+				c.subscribeStatistics()
+			} else {
+				c.unsubscribeStatistics()
+			}
+		default:
+			c.sendError("tópico inválido: " + topic)
+			return
+		}
+	}
+
+	c.sendMessage(wsMessage{Type: "inscricoes", Topics: c.topics()})
+}
+
+// subscribeStatistics inscreve o cliente no produtor compartilhado de estatísticas
+func (c *wsClient) subscribeStatistics() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unsubscribeStats != nil {
+		return
+	}
+
+	updates, unsubscribe := c.handler.stats.Subscribe()
+	c.unsubscribeStats = unsubscribe
+
+	go func() {
+		for stats := range updates {
+			c.sendMessage(wsMessage{Type: "evento", Topic: TopicStatistics, Data: stats})
+		}
+	}()
+}
+
+// unsubscribeStatistics cancela a inscrição nas estatísticas
+func (c *wsClient) unsubscribeStatistics() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unsubscribeStats != nil {
+		c.unsubscribeStats()
+		c.unsubscribeStats = nil
+	}
+}
+
+// sendMessage serializa e enfileira uma mensagem
+func (c *wsClient) sendMessage(msg wsMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		c.handler.logger.Error("erro ao serializar mensagem websocket", "erro", err)
+		return
+	}
+	c.enqueue(data)
+}
+
+// sendError enfileira uma mensagem de erro
+func (c *wsClient) sendError(message string) {
+	c.sendMessage(wsMessage{Type: "erro", Error: message})
+}
+
+// writeLoop envia as mensagens enfileiradas e pings periódicos
+func (c *wsClient) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+
+	for {
+		var opcode websocket.Opcode
+		var data []byte
+
+		select {
+		case <-c.done:
+			return
+		case data = <-c.send:
+			opcode = websocket.OpText
+		case <-ticker.C:
+			opcode = websocket.OpPing
+		}
+
+		c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := c.conn.WriteMessage(opcode, data); err != nil {
+			c.handler.logger.Error("erro ao enviar mensagem websocket", "erro", err)
+			c.close(websocket.CloseGoingAway, "")
+			return
+		}
+	}
+}
+
+// close encerra a conexão e cancela as inscrições do cliente
+func (c *wsClient) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.unsubscribeStatistics()
+		c.handler.unregister(c)
+		c.conn.Close(code, reason)
+	})
+}
@@ -0,0 +1,143 @@
+// Package idempotency armazena as respostas de requisições identificadas por
+// uma chave de idempotência, permitindo que repetições sejam respondidas sem
+// reprocessamento.
+package idempotency
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"api-itau/pkg/utils"
+)
+
+var (
+	// ErrKeyInProgress indica que outra requisição com a mesma chave ainda está em processamento
+	ErrKeyInProgress = errors.New("requisição com a mesma chave de idempotência em andamento")
+	// ErrFingerprintMismatch indica que a chave já foi usada com outro corpo de requisição
+	ErrFingerprintMismatch = errors.New("chave de idempotência reutilizada com outro corpo")
+)
+
+// Response é a resposta armazenada para uma chave
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// entry representa uma chave armazenada. Enquanto a requisição original
+// está em processamento, response é nil.
+type entry struct {
+	key         string
+	fingerprint string
+	expiresAt   time.Time
+	response    *Response
+}
+
+// Store mantém as chaves de idempotência em memória por um tempo limitado.
+// A quantidade de chaves é limitada: ao atingir o limite, as mais antigas
+// são descartadas.
+type Store struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	provider   utils.TimeProvider
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// NewStore cria um novo Store com o tempo de expiração e o limite de chaves informados
+func NewStore(ttl time.Duration, maxEntries int, provider utils.TimeProvider) *Store {
+	if provider == nil {
+		provider = utils.GetTimeProvider()
+	}
+	return &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		provider:   provider,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Fingerprint calcula a impressão digital de um corpo de requisição
+func Fingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Begin reserva a chave para uma nova requisição. Se a chave já possui uma
+// resposta armazenada com a mesma impressão digital, ela é retornada para
+// ser repetida; caso contrário, a chave fica reservada até Complete ou Release.
+func (s *Store) Begin(key, fingerprint string) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.provider.Now()
+	s.removeExpired(now)
+
+	if element, ok := s.entries[key]; ok {
+		e := element.Value.(*entry)
+		if e.fingerprint != fingerprint {
+			return nil, ErrFingerprintMismatch
+		}
+		if e.response == nil {
+			return nil, ErrKeyInProgress
+		}
+		return e.response, nil
+	}
+
+	for s.order.Len() >= s.maxEntries {
+		s.remove(s.order.Front())
+	}
+
+	s.entries[key] = s.order.PushBack(&entry{
+		key:         key,
+		fingerprint: fingerprint,
+		expiresAt:   now.Add(s.ttl),
+	})
+
+	return nil, nil
+}
+
+// Complete armazena a resposta de uma chave reservada
+func (s *Store) Complete(key string, response Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*entry).response = &response
+	}
+}
+
+// Release libera uma chave reservada sem armazenar resposta, permitindo que
+// a requisição seja processada novamente (ex.: após um erro interno)
+func (s *Store) Release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok && element.Value.(*entry).response == nil {
+		s.remove(element)
+	}
+}
+
+// removeExpired descarta as chaves expiradas. Como todas as chaves têm o
+// mesmo tempo de vida, a lista está ordenada pela expiração.
+func (s *Store) removeExpired(now time.Time) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		if element.Value.(*entry).expiresAt.After(now) {
+			return
+		}
+		s.remove(element)
+	}
+}
+
+// remove descarta uma chave
+func (s *Store) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*entry).key)
+}
@@ -38,6 +38,12 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// Unwrap expõe o http.ResponseWriter original para o http.ResponseController,
+// permitindo flush e controle de deadlines em respostas de streaming
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // LoggingMiddleware cria um middleware para logging de requisições HTTP
 func LoggingMiddleware(log logger.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -79,8 +85,10 @@ func LoggingMiddleware(log logger.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-// RecoveryMiddleware cria um middleware para recuperação de pânico
-func RecoveryMiddleware(log logger.Logger) func(http.Handler) http.Handler {
+// RecoveryMiddleware cria um middleware para recuperação de pânico. respond
+// envia a resposta de erro interno, no mesmo formato dos demais erros da
+// API; se for nil, é enviado um erro em texto simples.
+func RecoveryMiddleware(log logger.Logger, respond http.HandlerFunc) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
@@ -90,7 +98,11 @@ func RecoveryMiddleware(log logger.Logger) func(http.Handler) http.Handler {
 						"path", r.URL.Path,
 						"method", r.Method,
 					)
-					http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+					if respond == nil {
+						http.Error(w, "Erro interno do servidor", http.StatusInternalServerError)
+						return
+					}
+					respond(w, r)
 				}
 			}()
 
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"sync"
+
+	"api-itau/pkg/utils"
+)
+
+const (
+	// randAMask e randBMask limitam os 12 bits de rand_a e os 62 bits de rand_b
+	randAMask = 1<<12 - 1
+	randBMask = 1<<62 - 1
+)
+
+// idGenerator guarda o último identificador gerado, para que os IDs do
+// mesmo milissegundo sejam crescentes (RFC 9562, seção 6.2, método 2)
+var idGenerator struct {
+	mu    sync.Mutex
+	ms    uint64
+	randA uint16
+	randB uint64
+}
+
+// NewID gera um identificador UUIDv7 (RFC 9562). Os primeiros 48 bits contêm
+// o timestamp em milissegundos do TimeProvider; no mesmo milissegundo, ou se
+// o relógio voltar, os 74 bits seguintes são incrementados em vez de sorteados.
+// Assim os IDs gerados pelo processo são ordenáveis pela criação.
+func NewID() (string, error) {
+	ms := uint64(utils.GetTimeProvider().Now().UnixMilli())
+
+	idGenerator.mu.Lock()
+	if ms <= idGenerator.ms {
+		ms = idGenerator.ms
+		idGenerator.randB = (idGenerator.randB + 1) & randBMask
+		if idGenerator.randB == 0 {
+			idGenerator.randA = (idGenerator.randA + 1) & randAMask
+			if idGenerator.randA == 0 {
+				// Contador esgotado: avança o timestamp
+				ms++
+			}
+		}
+	}
+	if ms > idGenerator.ms {
+		var random [10]byte
+		if _, err := rand.Read(random[:]); err != nil {
+			idGenerator.mu.Unlock()
+			return "", err
+		}
+		idGenerator.randA = binary.BigEndian.Uint16(random[:2]) & randAMask
+		// O bit mais significativo zerado deixa espaço para incrementos
+		idGenerator.randB = binary.BigEndian.Uint64(random[2:]) & (randBMask >> 1)
+	}
+	idGenerator.ms = ms
+	randA, randB := idGenerator.randA, idGenerator.randB
+	idGenerator.mu.Unlock()
+
+	var uuid [16]byte
+	var buf8 [8]byte
+	binary.BigEndian.PutUint64(buf8[:], ms)
+	copy(uuid[:6], buf8[2:])
+	binary.BigEndian.PutUint16(uuid[6:8], randA)
+	binary.BigEndian.PutUint64(uuid[8:], randB)
+
+	uuid[6] = (uuid[6] & 0x0F) | 0x70 // versão 7
+	uuid[8] = (uuid[8] & 0x3F) | 0x80 // variante RFC 9562
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+
+	return string(buf[:]), nil
+}
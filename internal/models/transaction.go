@@ -1,18 +1,110 @@
 package models
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
+)
+
+var (
+	// ErrTransactionNotFound indica que a transação não existe ou já saiu do período de retenção
+	ErrTransactionNotFound = errors.New("transação não encontrada")
+	// ErrNegativeValue indica que o valor da transação é negativo
+	ErrNegativeValue = errors.New("valor não pode ser negativo")
+	// ErrFutureTimestamp indica que a data da transação está no futuro
+	ErrFutureTimestamp = errors.New("data da transação não pode estar no futuro")
+	// ErrInvalidType indica que o tipo da transação não é débito nem crédito
+	ErrInvalidType = errors.New("tipo deve ser débito ou crédito")
+)
+
+// Tipos de transação
+const (
+	TypeDebit  = "debito"
+	TypeCredit = "credito"
 )
 
+// ParseType converte o tipo de uma transação, com ou sem acento e sem
+// diferenciar maiúsculas de minúsculas, para TypeDebit ou TypeCredit
+func ParseType(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "debito", "débito":
+		return TypeDebit, nil
+	case "credito", "crédito":
+		return TypeCredit, nil
+	default:
+		return "", ErrInvalidType
+	}
+}
+
 type Transaction struct {
-	Value     float64   `json:"valor"`
-	Timestamp time.Time `json:"dataHora,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Value     decimal.Decimal `json:"valor"`
+	Currency  string          `json:"moeda"`
+	Timestamp time.Time       `json:"dataHora,omitempty"`
+	// Type é TypeDebit, TypeCredit ou vazio quando não informado
+	Type        string `json:"tipo,omitempty"`
+	Description string `json:"descricao,omitempty"`
+	// Labels são rótulos livres de baixa cardinalidade (ex.: canal, loja)
+	Labels map[string]string `json:"rotulos,omitempty"`
+}
+
+// TransactionOption define um campo opcional de uma nova transação
+type TransactionOption func(*Transaction)
+
+// WithCurrency define a moeda da transação (código ISO 4217)
+func WithCurrency(code string) TransactionOption {
+	return func(t *Transaction) {
+		t.Currency = code
+	}
+}
+
+// WithType define o tipo da transação (débito ou crédito)
+func WithType(kind string) TransactionOption {
+	return func(t *Transaction) {
+		t.Type = kind
+	}
+}
+
+// WithLabels define os rótulos da transação
+func WithLabels(labels map[string]string) TransactionOption {
+	return func(t *Transaction) {
+		t.Labels = labels
+	}
+}
+
+// WithDescription define a descrição da transação
+func WithDescription(description string) TransactionOption {
+	return func(t *Transaction) {
+		t.Description = description
+	}
 }
 
 func (t *Transaction) Validate() error {
-	if t.Value < 0 {
-		return fmt.Errorf("Valor não pode ser negativo")
+	if t.Value.Sign() < 0 {
+		return ErrNegativeValue
+	}
+
+	// Se a moeda não for informada, usa a moeda padrão
+	if t.Currency == "" {
+		t.Currency = currency.Default
+	}
+
+	code, err := currency.Normalize(t.Currency)
+	if err != nil {
+		return err
+	}
+	t.Currency = code
+
+	if t.Type != "" {
+		kind, err := ParseType(t.Type)
+		if err != nil {
+			return err
+		}
+		t.Type = kind
 	}
 
 	// Se o timestamp estiver zerado, usa o tempo atual
@@ -21,21 +113,31 @@ func (t *Transaction) Validate() error {
 	}
 
 	if t.Timestamp.After(time.Now()) {
-		return fmt.Errorf("data da transação não pode estar no futuro")
+		return ErrFutureTimestamp
 	}
 
 	return nil
 }
 
-func NewTransaction(value float64, timestamp time.Time) (*Transaction, error) {
+func NewTransaction(value decimal.Decimal, timestamp time.Time, opts ...TransactionOption) (*Transaction, error) {
 	t := &Transaction{
 		Value:     value,
 		Timestamp: timestamp,
 	}
 
+	for _, opt := range opts {
+		opt(t)
+	}
+
 	if err := t.Validate(); err != nil {
 		return nil, fmt.Errorf("erro ao criar transação: %w", err)
 	}
 
+	id, err := NewID()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar id da transação: %w", err)
+	}
+	t.ID = id
+
 	return t, nil
 }
@@ -0,0 +1,99 @@
+// Package problem implementa respostas de erro no formato RFC 7807
+// (application/problem+json).
+package problem
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentType é o media type das respostas de erro RFC 7807
+const ContentType = "application/problem+json"
+
+// typePrefix identifica os tipos de problema definidos pela API. Os tipos são
+// URNs, já que não há documentação publicada em uma URL para cada um.
+const typePrefix = "urn:api-itau:problema:"
+
+// Problem representa um objeto de detalhes de problema (RFC 7807). Os membros
+// de extensão são serializados no mesmo nível dos membros padrão.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// New cria um problema para o status HTTP informado. O código do erro define
+// o tipo do problema e também é incluído como membro de extensão "code".
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:       typePrefix + code,
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     detail,
+		Extensions: map[string]interface{}{"code": code},
+	}
+}
+
+// With adiciona um membro de extensão ao problema
+func (p *Problem) With(key string, value interface{}) *Problem {
+	p.Extensions[key] = value
+	return p
+}
+
+// MarshalJSON serializa o problema com os membros de extensão
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+// Write envia o problema com o status e o Content-Type apropriados
+func Write(w http.ResponseWriter, p *Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		p = New(http.StatusInternalServerError, "internal_error", "Erro ao processar resposta")
+		body, _ = json.Marshal(p)
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(append(body, '\n'))
+}
+
+// Accepted indica se o header Accept da requisição solicita explicitamente
+// application/problem+json
+func Accepted(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || mediaType != ContentType {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"math"
+
+	"api-itau/handlers"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/sketch"
+)
+
+// rounding define a escala e o modo de arredondamento dos valores calculados
+type rounding struct {
+	scale int32
+	mode  decimal.RoundingMode
+}
+
+// aggregate acumula contagem, soma, mínimo e máximo de um conjunto de transações.
+// Soma, mínimo e máximo são exatos. Média e soma dos quadrados dos desvios,
+// usadas apenas na variância, são mantidas em ponto flutuante pelo algoritmo
+// de Welford, o que permite combinar agregados sem perder estabilidade numérica.
+type aggregate struct {
+	count int
+	sum   decimal.Decimal
+	min   decimal.Decimal
+	max   decimal.Decimal
+	mean  float64
+	m2    float64
+}
+
+// add inclui um valor no agregado
+func (a *aggregate) add(value decimal.Decimal) {
+	if a.count == 0 || value.Cmp(a.min) < 0 {
+		a.min = value
+	}
+	if a.count == 0 || value.Cmp(a.max) > 0 {
+		a.max = value
+	}
+	a.count++
+	a.sum = a.sum.Add(value)
+
+	f := value.Float64()
+	delta := f - a.mean
+	a.mean += delta / float64(a.count)
+	a.m2 += delta * (f - a.mean)
+}
+
+// merge combina outro agregado a este
+func (a *aggregate) merge(other *aggregate) {
+	if other.count == 0 {
+		return
+	}
+	if a.count == 0 {
+		*a = *other
+		return
+	}
+	if other.min.Cmp(a.min) < 0 {
+		a.min = other.min
+	}
+	if other.max.Cmp(a.max) > 0 {
+		a.max = other.max
+	}
+
+	count := a.count + other.count
+	delta := other.mean - a.mean
+	a.m2 += other.m2 + delta*delta*float64(a.count)*float64(other.count)/float64(count)
+	a.mean += delta * float64(other.count) / float64(count)
+	a.count = count
+	a.sum = a.sum.Add(other.sum)
+}
+
+// variance retorna a variância populacional dos valores agregados
+func (a *aggregate) variance() float64 {
+	if a.count == 0 {
+		return 0
+	}
+	return a.m2 / float64(a.count)
+}
+
+// toResponse converte o agregado na resposta de estatísticas, arredondando
+// a soma e a média conforme r. Um agregado vazio resulta em todos os valores zerados.
+func (a *aggregate) toResponse(r rounding) *handlers.StatisticsResponse {
+	if a.count == 0 {
+		return &handlers.StatisticsResponse{}
+	}
+
+	return &handlers.StatisticsResponse{
+		Count: a.count,
+		Sum:   a.sum.Round(r.scale, r.mode),
+		Avg:   a.sum.QuoInt(int64(a.count), r.scale, r.mode),
+		Min:   a.min,
+		Max:   a.max,
+	}
+}
+
+// group combina o agregado de um conjunto de transações com o sketch da
+// distribuição dos seus valores. O sketch é nil quando a distribuição não é
+// necessária, como nas células e rótulos dos buckets e nos totais de
+// consultas sem percentis.
+type group struct {
+	aggregate
+	values *sketch.DDSketch
+}
+
+// newGroup cria um grupo vazio, com sketch apenas se withDistribution
+func newGroup(withDistribution bool) *group {
+	g := &group{}
+	if withDistribution {
+		g.values = newValueSketch()
+	}
+	return g
+}
+
+// add inclui um valor no grupo, e na sua distribuição quando o grupo
+// possui um sketch
+func (g *group) add(value decimal.Decimal) {
+	g.aggregate.add(value)
+	if g.values != nil {
+		g.values.Add(value.Float64())
+	}
+}
+
+// merge combina outro grupo a este. A distribuição só é combinada quando
+// este grupo possui um sketch.
+func (g *group) merge(other *group) {
+	g.aggregate.merge(&other.aggregate)
+	if g.values != nil && other.values != nil {
+		g.values.Merge(other.values)
+	}
+}
+
+// response converte o grupo na resposta de estatísticas, incluindo as
+// métricas de distribuição quando percentis forem solicitados
+func (g *group) response(r rounding, percentiles []float64) *handlers.StatisticsResponse {
+	stats := g.toResponse(r)
+	if len(percentiles) > 0 && g.values != nil {
+		g.withDistribution(stats, g.values, percentiles)
+	}
+	return stats
+}
+
+// withDistribution acrescenta à resposta a variância, o desvio padrão e os
+// percentis solicitados, estimados a partir do sketch informado
+func (a *aggregate) withDistribution(stats *handlers.StatisticsResponse, values *sketch.DDSketch, percentiles []float64) {
+	variance := a.variance()
+	stdDev := math.Sqrt(variance)
+	stats.Variance = &variance
+	stats.StdDev = &stdDev
+
+	stats.Percentiles = make(map[string]float64, len(percentiles))
+	for _, p := range percentiles {
+		stats.Percentiles[handlers.PercentileKey(p)] = values.Quantile(p / 100)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"api-itau/internal/models"
+	"api-itau/pkg/sketch"
+)
+
+const (
+	// sketchRelativeAccuracy é o erro relativo máximo dos percentis
+	sketchRelativeAccuracy = 0.01
+	// sketchMaxBins limita a memória de cada sketch de valores
+	sketchMaxBins = 2048
+)
+
+// cellKey identifica as transações de um bucket com a mesma moeda e o mesmo
+// tipo. As consultas filtradas ou agrupadas por essas dimensões combinam as
+// células correspondentes.
+type cellKey struct {
+	currency string
+	kind     string
+}
+
+// labelKey identifica as transações de um bucket com o mesmo valor de um
+// rótulo. A moeda faz parte da chave para que o agrupamento por rótulo
+// respeite o filtro por moeda.
+type labelKey struct {
+	currency string
+	name     string
+	value    string
+}
+
+// bucket agrega as transações cujo timestamp cai em um mesmo segundo, no
+// total, separadas por moeda e tipo e por valor de cada rótulo. Apenas o
+// total mantém o sketch da distribuição; as células e os rótulos guardam só
+// os agregados. As transações também são mantidas individualmente para
+// consultas por id, até que o bucket seja aparado pelo limite de transações
+// armazenadas.
+type bucket struct {
+	second int64
+	group
+	cells  map[cellKey]*group
+	labels map[labelKey]*group
+	top    *topK
+	// bins conta as transações do bucket por faixa do histograma
+	bins         []int
+	transactions []models.Transaction
+	// trimmed indica que as transações individuais foram descartadas; as
+	// novas transações do segundo passam a compor apenas os agregados
+	trimmed bool
+}
+
+// add inclui uma transação no bucket. labels são os rótulos da transação já
+// limitados pela cardinalidade máxima, usados no agrupamento.
+func (b *bucket) add(t models.Transaction, labels map[string]string) {
+	if b.values == nil {
+		b.values = newValueSketch()
+	}
+	b.group.add(t.Value)
+
+	if b.cells == nil {
+		b.cells = make(map[cellKey]*group)
+	}
+	key := cellKey{currency: t.Currency, kind: t.Type}
+	g, ok := b.cells[key]
+	if !ok {
+		g = &group{}
+		b.cells[key] = g
+	}
+	g.add(t.Value)
+
+	if len(labels) > 0 && b.labels == nil {
+		b.labels = make(map[labelKey]*group)
+	}
+	for name, value := range labels {
+		key := labelKey{currency: t.Currency, name: name, value: value}
+		g, ok := b.labels[key]
+		if !ok {
+			g = &group{}
+			b.labels[key] = g
+		}
+		g.add(t.Value)
+	}
+
+	if !b.trimmed {
+		b.transactions = append(b.transactions, t)
+	}
+}
+
+// newValueSketch cria o sketch usado para estimar percentis dos valores
+func newValueSketch() *sketch.DDSketch {
+	return sketch.NewDDSketch(sketchRelativeAccuracy, sketchMaxBins)
+}
+
+// bucketRing é um buffer circular de buckets indexado pelo segundo Unix.
+// Cada posição é reaproveitada quando um segundo mais recente a ocupa,
+// mantendo a memória dos agregados proporcional à retenção e não ao volume.
+// As transações individuais dos buckets são limitadas à parte, por
+// StatisticsService.maxTransactions.
+type bucketRing struct {
+	buckets []bucket
+	evict   func(*bucket)
+}
+
+// newBucketRing cria um buffer circular com a quantidade de segundos informada.
+// A função evict, se informada, é chamada com cada bucket antes de ele ser descartado.
+func newBucketRing(seconds int, evict func(*bucket)) *bucketRing {
+	return &bucketRing{
+		buckets: make([]bucket, seconds),
+		evict:   evict,
+	}
+}
+
+// slot retorna a posição do buffer correspondente ao segundo
+func (r *bucketRing) slot(second int64) *bucket {
+	n := int64(len(r.buckets))
+	return &r.buckets[((second%n)+n)%n]
+}
+
+// bucketFor retorna o bucket do segundo informado para escrita,
+// descartando o conteúdo de um segundo antigo que ocupava a mesma posição
+func (r *bucketRing) bucketFor(second int64) *bucket {
+	b := r.slot(second)
+	if b.second != second {
+		r.discard(b)
+		*b = bucket{second: second}
+	}
+	return b
+}
+
+// get retorna o bucket do segundo informado, se houver transações nele
+func (r *bucketRing) get(second int64) (*bucket, bool) {
+	b := r.slot(second)
+	if b.second != second || b.count == 0 {
+		return nil, false
+	}
+	return b, true
+}
+
+// reset descarta todos os buckets
+func (r *bucketRing) reset() {
+	for i := range r.buckets {
+		r.discard(&r.buckets[i])
+		r.buckets[i] = bucket{}
+	}
+}
+
+// discard notifica o descarte de um bucket com transações
+func (r *bucketRing) discard(b *bucket) {
+	if r.evict != nil && b.count > 0 {
+		r.evict(b)
+	}
+}
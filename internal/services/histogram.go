@@ -0,0 +1,57 @@
+package services
+
+import (
+	"sort"
+
+	"api-itau/handlers"
+	"api-itau/pkg/decimal"
+)
+
+// histogram conta as transações por faixa de valor. A faixa i inclui os
+// valores maiores que bounds[i-1] e menores ou iguais a bounds[i]; a última
+// faixa inclui os valores maiores que o último limite.
+type histogram struct {
+	bounds []decimal.Decimal
+	counts []int
+}
+
+// newHistogram cria um histograma vazio com os limites informados
+func newHistogram(bounds []decimal.Decimal) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]int, len(bounds)+1),
+	}
+}
+
+// bin retorna a faixa do valor
+func (h *histogram) bin(value decimal.Decimal) int {
+	return sort.Search(len(h.bounds), func(i int) bool {
+		return value.Cmp(h.bounds[i]) <= 0
+	})
+}
+
+// subtract remove as contagens de um bucket que saiu da janela
+func (h *histogram) subtract(counts []int) {
+	for i, count := range counts {
+		h.counts[i] -= count
+	}
+}
+
+// response converte as contagens para a resposta da API
+func (h *histogram) response() *handlers.HistogramResponse {
+	response := &handlers.HistogramResponse{
+		Buckets: make([]handlers.HistogramBucket, len(h.counts)),
+	}
+	for i, count := range h.counts {
+		bucket := handlers.HistogramBucket{Count: count}
+		if i > 0 {
+			bucket.Lower = &h.bounds[i-1]
+		}
+		if i < len(h.bounds) {
+			bucket.Upper = &h.bounds[i]
+		}
+		response.Buckets[i] = bucket
+		response.Count += count
+	}
+	return response
+}
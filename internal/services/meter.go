@@ -0,0 +1,88 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// meterTick é o intervalo entre as atualizações das médias móveis, como no
+// load average do Unix
+const meterTick = 5 * time.Second
+
+// meterPeriods são os períodos das médias móveis exponenciais
+var meterPeriods = [...]time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// ewma é uma média móvel exponencial atualizada a cada meterTick. O peso de
+// cada atualização decai com a razão entre meterTick e o período da média.
+type ewma struct {
+	alpha float64
+	rate  float64
+}
+
+// newEWMA cria uma média móvel do período informado, iniciada em zero
+func newEWMA(period time.Duration) ewma {
+	return ewma{alpha: 1 - math.Exp(-meterTick.Seconds()/period.Seconds())}
+}
+
+// update inclui a taxa medida no último intervalo
+func (e *ewma) update(instant float64) {
+	e.rate += e.alpha * (instant - e.rate)
+}
+
+// decay aplica n intervalos sem transações
+func (e *ewma) decay(n int64) {
+	e.rate *= math.Pow(1-e.alpha, float64(n))
+}
+
+// meter mede a taxa de chegada de transações e de valor por segundo. As
+// transações são acumuladas até o fim do intervalo corrente, e as médias
+// são atualizadas sob demanda pelo relógio informado em tick, de modo que
+// não há goroutine de atualização.
+type meter struct {
+	lastTick time.Time
+	count    int
+	value    float64
+	counts   [len(meterPeriods)]ewma
+	values   [len(meterPeriods)]ewma
+}
+
+// newMeter cria um medidor iniciado no instante informado
+func newMeter(now time.Time) *meter {
+	m := &meter{lastTick: now}
+	for i, period := range meterPeriods {
+		m.counts[i] = newEWMA(period)
+		m.values[i] = newEWMA(period)
+	}
+	return m
+}
+
+// mark registra a chegada de uma transação
+func (m *meter) mark(now time.Time, value float64) {
+	m.tick(now)
+	m.count++
+	m.value += value
+}
+
+// tick atualiza as médias com os intervalos encerrados até now. O primeiro
+// intervalo usa as transações acumuladas; os demais não tiveram transações.
+func (m *meter) tick(now time.Time) {
+	ticks := int64(now.Sub(m.lastTick) / meterTick)
+	if ticks <= 0 {
+		return
+	}
+
+	countRate := float64(m.count) / meterTick.Seconds()
+	valueRate := m.value / meterTick.Seconds()
+	for i := range meterPeriods {
+		m.counts[i].update(countRate)
+		m.values[i].update(valueRate)
+		if ticks > 1 {
+			m.counts[i].decay(ticks - 1)
+			m.values[i].decay(ticks - 1)
+		}
+	}
+
+	m.count = 0
+	m.value = 0
+	m.lastTick = m.lastTick.Add(time.Duration(ticks) * meterTick)
+}
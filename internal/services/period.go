@@ -0,0 +1,65 @@
+package services
+
+import (
+	"time"
+
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/utils"
+)
+
+// periodGranularities são as granularidades agregadas para cada fuso horário
+var periodGranularities = [...]string{utils.GranularityMinute, utils.GranularityHour, utils.GranularityDay}
+
+// periodAggregator agrega as transações em janelas fixas de calendário
+// (tumbling windows) de uma granularidade, alinhadas a um fuso horário. Cada
+// período é indexado pelo seu início e descartado quando termina antes do
+// período de retenção.
+type periodAggregator struct {
+	granularity string
+	location    *time.Location
+	periods     map[int64]*aggregate
+}
+
+// newPeriodAggregator cria um agregador vazio
+func newPeriodAggregator(granularity string, location *time.Location) *periodAggregator {
+	return &periodAggregator{
+		granularity: granularity,
+		location:    location,
+		periods:     make(map[int64]*aggregate),
+	}
+}
+
+// add inclui o valor no período que contém o timestamp, retornando true
+// quando um novo período foi criado
+func (p *periodAggregator) add(timestamp time.Time, value decimal.Decimal) bool {
+	period, err := utils.CalendarPeriod(timestamp, p.granularity, p.location)
+	if err != nil {
+		return false
+	}
+
+	key := period.Start.Unix()
+	a, ok := p.periods[key]
+	if !ok {
+		a = &aggregate{}
+		p.periods[key] = a
+	}
+	a.add(value)
+
+	return !ok
+}
+
+// get retorna o agregado do período que começa em start
+func (p *periodAggregator) get(start time.Time) (*aggregate, bool) {
+	a, ok := p.periods[start.Unix()]
+	return a, ok
+}
+
+// prune descarta os períodos que terminaram antes de cutoff
+func (p *periodAggregator) prune(cutoff time.Time) {
+	for key := range p.periods {
+		period, err := utils.CalendarPeriod(time.Unix(key, 0), p.granularity, p.location)
+		if err != nil || !period.End.After(cutoff) {
+			delete(p.periods, key)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"api-itau/handlers"
+	"api-itau/internal/models"
+)
+
+// selection acumula o total e os grupos de uma consulta de estatísticas a
+// partir dos buckets da janela. Sem filtros, os agregados dos buckets são
+// combinados diretamente; com filtros, as transações de cada bucket são
+// percorridas e apenas as que satisfazem o filtro são agregadas. Como só o
+// total dos buckets mantém um sketch, a distribuição por moeda e por grupo
+// é estimada a partir das transações armazenadas.
+type selection struct {
+	query            handlers.StatisticsQuery
+	withDistribution bool
+	byLabel          bool
+	total            *group
+	groups           map[string]*group
+}
+
+// newSelection cria a seleção vazia de uma consulta
+func newSelection(query handlers.StatisticsQuery) *selection {
+	sel := &selection{
+		query:            query,
+		withDistribution: len(query.Percentiles) > 0,
+		byLabel: query.GroupBy != "" && query.GroupBy != handlers.GroupByCurrency &&
+			query.GroupBy != handlers.GroupByType,
+	}
+	sel.total = newGroup(sel.withDistribution)
+	if query.GroupBy != "" {
+		sel.groups = make(map[string]*group)
+	}
+	return sel
+}
+
+// mergeBucket combina os agregados do bucket que pertencem à seleção
+func (sel *selection) mergeBucket(b *bucket) {
+	currency := sel.query.Currency
+	if currency == "" {
+		sel.total.merge(&b.group)
+	}
+
+	for key, g := range b.cells {
+		if currency != "" && key.currency != currency {
+			continue
+		}
+		if currency != "" {
+			sel.total.merge(g)
+		}
+		if sel.groups != nil && !sel.byLabel {
+			sel.group(groupName(sel.query.GroupBy, key)).merge(g)
+		}
+	}
+
+	if sel.byLabel {
+		for key, g := range b.labels {
+			if key.name != sel.query.GroupBy || (currency != "" && key.currency != currency) {
+				continue
+			}
+			sel.group(key.value).merge(g)
+		}
+	}
+}
+
+// scanBucket agrega as transações do bucket que satisfazem a moeda e o
+// filtro da consulta. labelGroup converte o valor de um rótulo no nome do
+// seu grupo, respeitando a cardinalidade máxima, ou em vazio quando o
+// rótulo não é agrupado.
+func (sel *selection) scanBucket(b *bucket, labelGroup func(name, value string) string) {
+	for _, t := range b.transactions {
+		if sel.query.Currency != "" && t.Currency != sel.query.Currency {
+			continue
+		}
+		if !sel.query.Filter.Match(t) {
+			continue
+		}
+
+		sel.total.add(t.Value)
+		if name := sel.groupOf(t, labelGroup); name != "" {
+			sel.group(name).add(t.Value)
+		}
+	}
+}
+
+// sketchBucket acrescenta às distribuições do total restrito a uma moeda e
+// dos grupos os valores das transações armazenadas no bucket, cujos
+// agregados já foram combinados por mergeBucket. Só é necessária quando
+// percentis são solicitados junto com moeda ou agrupar.
+func (sel *selection) sketchBucket(b *bucket, labelGroup func(name, value string) string) {
+	for _, t := range b.transactions {
+		if sel.query.Currency != "" {
+			if t.Currency != sel.query.Currency {
+				continue
+			}
+			sel.total.values.Add(t.Value.Float64())
+		}
+
+		// Apenas grupos já criados pelos agregados recebem valores
+		if g, ok := sel.groups[sel.groupOf(t, labelGroup)]; ok {
+			g.values.Add(t.Value.Float64())
+		}
+	}
+}
+
+// needsSketchScan indica se a distribuição da consulta depende das
+// transações armazenadas, e não apenas do sketch do total dos buckets
+func (sel *selection) needsSketchScan() bool {
+	return sel.withDistribution && (sel.query.Currency != "" || sel.groups != nil)
+}
+
+// groupOf retorna o nome do grupo da transação na consulta, ou vazio quando
+// a consulta não é agrupada ou a transação não pertence a nenhum grupo
+func (sel *selection) groupOf(t models.Transaction, labelGroup func(name, value string) string) string {
+	if sel.groups == nil {
+		return ""
+	}
+	if !sel.byLabel {
+		return groupName(sel.query.GroupBy, cellKey{currency: t.Currency, kind: t.Type})
+	}
+	if value, ok := t.Labels[sel.query.GroupBy]; ok {
+		return labelGroup(sel.query.GroupBy, value)
+	}
+	return ""
+}
+
+// group retorna o grupo de nome name, criando-o se necessário
+func (sel *selection) group(name string) *group {
+	g, ok := sel.groups[name]
+	if !ok {
+		g = newGroup(sel.withDistribution)
+		sel.groups[name] = g
+	}
+	return g
+}
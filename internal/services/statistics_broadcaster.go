@@ -0,0 +1,154 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/pkg/logger"
+)
+
+// StatisticsBroadcaster implementa a interface handlers.StatisticsSubscriber.
+// Um único produtor calcula as estatísticas a cada intervalo, ou assim que
+// uma transação altera a janela, e distribui o resultado para todos os
+// inscritos. Inscritos lentos recebem apenas o snapshot mais recente.
+type StatisticsBroadcaster struct {
+	stats       *StatisticsService
+	interval    time.Duration
+	logger      logger.Logger
+	mu          sync.Mutex
+	subscribers map[chan handlers.StatisticsResponse]struct{}
+	changed     chan struct{}
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewStatisticsBroadcaster cria uma nova instância do StatisticsBroadcaster
+func NewStatisticsBroadcaster(stats *StatisticsService, interval time.Duration, log logger.Logger) *StatisticsBroadcaster {
+	b := &StatisticsBroadcaster{
+		stats:       stats,
+		interval:    interval,
+		logger:      log,
+		subscribers: make(map[chan handlers.StatisticsResponse]struct{}),
+		changed:     make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	stats.OnChange(b.notify)
+	return b
+}
+
+// Start inicia o produtor em uma goroutine
+func (b *StatisticsBroadcaster) Start() {
+	go b.run()
+}
+
+// Subscribe registra um novo inscrito, que recebe imediatamente o snapshot
+// atual. A função retornada cancela a inscrição. O canal é fechado quando o
+// broadcaster é encerrado.
+func (b *StatisticsBroadcaster) Subscribe() (<-chan handlers.StatisticsResponse, func()) {
+	ch := make(chan handlers.StatisticsResponse, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		close(ch)
+		return ch, func() {}
+	default:
+	}
+
+	b.subscribers[ch] = struct{}{}
+	if stats, err := b.stats.GetStatistics(); err == nil {
+		deliver(ch, *stats)
+	}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Close encerra o produtor e fecha o canal de todos os inscritos
+func (b *StatisticsBroadcaster) Close() {
+	b.closeOnce.Do(func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		close(b.done)
+		for ch := range b.subscribers {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+
+		b.logger.Info("broadcaster de estatísticas encerrado")
+	})
+}
+
+// notify sinaliza que a janela foi alterada. Notificações consecutivas são
+// agrupadas enquanto o produtor ainda não as processou.
+func (b *StatisticsBroadcaster) notify() {
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run é o laço do produtor
+func (b *StatisticsBroadcaster) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+		case <-b.changed:
+		}
+		b.publish()
+	}
+}
+
+// publish calcula as estatísticas uma única vez e as envia a todos os inscritos
+func (b *StatisticsBroadcaster) publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subscribers) == 0 {
+		return
+	}
+
+	stats, err := b.stats.GetStatistics()
+	if err != nil {
+		b.logger.Error("erro ao calcular estatísticas para os inscritos", "erro", err)
+		return
+	}
+
+	for ch := range b.subscribers {
+		deliver(ch, *stats)
+	}
+}
+
+// deliver envia o snapshot sem bloquear, substituindo um snapshot ainda não lido
+func deliver(ch chan handlers.StatisticsResponse, stats handlers.StatisticsResponse) {
+	for {
+		select {
+		case ch <- stats:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
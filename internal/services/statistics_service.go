@@ -1,69 +1,451 @@
 package services
 
 import (
-	"math"
+	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"api-itau/config"
 	"api-itau/handlers"
 	"api-itau/internal/models"
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
 	"api-itau/pkg/logger"
 	"api-itau/pkg/utils"
 )
 
-// StatisticsService implementa a interface handlers.StatisticsService
+// StatisticsService implementa a interface handlers.StatisticsService.
+// As transações são agregadas em buckets de um segundo, de modo que a
+// inclusão é O(1) e o cálculo das estatísticas é O(janela), independente
+// do volume de transações. Os buckets são mantidos pelo período de
+// retenção, o que permite consultar janelas maiores que a padrão e
+// localizar transações pelo id. As transações individuais ocupam memória
+// proporcional ao volume e por isso são limitadas a maxTransactions: acima
+// do limite, as dos segundos mais antigos são descartadas e continuam
+// apenas nos agregados.
 type StatisticsService struct {
-	transactions []models.Transaction
-	window       *utils.SlidingWindow
-	mu           sync.RWMutex
-	logger       logger.Logger
+	buckets   *bucketRing
+	index     map[string]transactionRef
+	window    *utils.SlidingWindow
+	retention *utils.SlidingWindow
+	provider  utils.TimeProvider
+	rounding  rounding
+	rates     currency.Rates
+	listeners []func()
+	// labelValues guarda, para até labelMaxNames rótulos, os valores que
+	// possuem grupo próprio (até labelMaxValues por rótulo) e o último
+	// segundo em que cada um foi visto. Os valores expiram com a retenção;
+	// labelsExpiredAt é o início da retenção na última expiração.
+	labelValues     map[string]map[string]int64
+	labelMaxNames   int
+	labelMaxValues  int
+	labelsExpiredAt int64
+	// topMax é a maior quantidade de transações de GET /estatistica/top, e
+	// também a capacidade do ranking mantido em cada bucket
+	topMax int
+	// stored é a quantidade de transações individuais nos buckets, limitada
+	// a maxTransactions. Os segundos anteriores a trimFrom já foram aparados.
+	stored          int
+	maxTransactions int
+	trimFrom        int64
+	// histogram mantém as contagens por faixa de valor da janela padrão,
+	// atualizadas quando transações entram na janela e quando os buckets a
+	// partir de histogramStart saem dela
+	histogram      *histogram
+	histogramStart int64
+	// meter mede a taxa de chegada das transações aceitas
+	meter *meter
+	// periods agrega as transações por período de calendário, indexado pelo
+	// fuso horário e pela granularidade. Os períodos são mantidos por
+	// periodRetention, independente da retenção dos buckets.
+	periods         map[string]map[string]*periodAggregator
+	periodTimezones []string
+	periodRetention time.Duration
+	mu              sync.RWMutex
+	logger          logger.Logger
+}
+
+// transactionRef localiza uma transação dentro dos buckets
+type transactionRef struct {
+	second   int64
+	position int
 }
 
 // NewStatisticsService cria uma nova instância do StatisticsService
 func NewStatisticsService(cfg *config.Config, log logger.Logger) *StatisticsService {
+	provider := utils.GetTimeProvider()
+
+	retentionSeconds := cfg.Stats.RetentionSeconds
+	if retentionSeconds < cfg.Stats.WindowSeconds {
+		retentionSeconds = cfg.Stats.WindowSeconds
+	}
+
 	duration := time.Duration(cfg.Stats.WindowSeconds) * time.Second
-	window := utils.NewSlidingWindow(duration, utils.GetTimeProvider())
+	retention := time.Duration(retentionSeconds) * time.Second
+
+	// A configuração é validada em config.Load, então um modo inválido só
+	// ocorre em configurações montadas manualmente e usa o padrão
+	mode, err := decimal.ParseRoundingMode(cfg.Stats.Rounding)
+	if err != nil {
+		mode = decimal.HalfEven
+	}
 
-	return &StatisticsService{
-		transactions: make([]models.Transaction, 0),
-		window:       window,
-		logger:       log,
+	s := &StatisticsService{
+		index:    make(map[string]transactionRef),
+		rounding: rounding{scale: int32(cfg.Stats.Scale), mode: mode},
+
+		labelValues:    make(map[string]map[string]int64),
+		labelMaxValues: cfg.Stats.LabelMaxValues,
+		topMax:         cfg.Stats.TopMax,
+		// Zero (configurações montadas manualmente) não limita os rótulos
+		// agrupados nem as transações
+		labelMaxNames:   cfg.Stats.LabelMaxNames,
+		maxTransactions: cfg.Stats.MaxTransactions,
+		histogram:       newHistogram(cfg.Stats.HistogramBounds),
+		meter:           newMeter(provider.Now()),
+		periodRetention: cfg.Stats.PeriodRetention,
+		window:          utils.NewSlidingWindow(duration, provider),
+		retention:       utils.NewSlidingWindow(retention, provider),
+		provider:        provider,
+		logger:          log,
+	}
+	// O buffer guarda ao menos duas janelas para a comparação com a anterior
+	s.buckets = newBucketRing(max(retentionSeconds, 2*cfg.Stats.WindowSeconds), s.evictBucket)
+	s.histogramStart, _ = bucketRange(s.window.GetWindow())
+
+	// Os fusos horários são validados em config.Load; fusos inválidos só
+	// ocorrem em configurações montadas manualmente e são ignorados
+	for _, name := range cfg.Stats.PeriodTimezones {
+		if _, err := time.LoadLocation(name); err == nil {
+			s.periodTimezones = append(s.periodTimezones, name)
+		}
 	}
+	s.resetPeriods()
+
+	if len(cfg.Currency.Rates) > 0 {
+		s.rates = currency.NewStaticRates(cfg.Currency.Base, cfg.Currency.Rates)
+	}
+
+	return s
 }
 
-// AddTransaction adiciona uma nova transação
-func (s *StatisticsService) AddTransaction(t models.Transaction) {
+// SetRates substitui a tabela de cotações usada no total consolidado.
+// nil desativa a consolidação.
+func (s *StatisticsService) SetRates(rates currency.Rates) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.cleanOldTransactions()
-	s.transactions = append(s.transactions, t)
+	s.rates = rates
+}
+
+// OnChange registra uma função chamada sempre que uma transação é incluída
+// nos buckets ou as transações são removidas. As funções são chamadas fora
+// do lock e não devem bloquear.
+func (s *StatisticsService) OnChange(listener func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// AddTransaction adiciona uma nova transação, retornando se ela foi
+// armazenada individualmente e pode ser consultada pelo id
+func (s *StatisticsService) AddTransaction(t models.Transaction) bool {
+	s.mu.Lock()
+	added, stored := s.addTransaction(t)
+	s.mu.Unlock()
+
+	if added {
+		s.notifyChange()
+	}
+
+	return stored
+}
+
+// AddTransactions adiciona um lote de transações com uma única aquisição do lock
+func (s *StatisticsService) AddTransactions(transactions []models.Transaction) {
+	s.mu.Lock()
+	added := 0
+	for _, t := range transactions {
+		if ok, _ := s.addTransaction(t); ok {
+			added++
+		}
+	}
+	s.mu.Unlock()
+
+	if added > 0 {
+		s.notifyChange()
+	}
+}
+
+// addTransaction inclui a transação no bucket do seu segundo, retornando
+// added false quando ela está fora do período de retenção e stored false
+// quando ela não foi armazenada individualmente. Deve ser chamada com o
+// lock de escrita adquirido.
+func (s *StatisticsService) addTransaction(t models.Transaction) (added, stored bool) {
+	// Os períodos de calendário têm retenção própria e recebem também
+	// transações anteriores à retenção dos buckets
+	s.addToPeriods(t)
+
+	second := t.Timestamp.Unix()
+	first, last := bucketRange(s.retention.GetWindow())
+	if second < first || second > last {
+		s.logger.Info("transação fora do período de retenção ignorada nas estatísticas",
+			"valor", t.Value,
+			"moeda", t.Currency,
+			"dataHora", t.Timestamp,
+		)
+		return false, false
+	}
+
+	// Transações criadas sem moeda (ex.: diretamente pelo serviço) usam a padrão
+	if t.Currency == "" {
+		t.Currency = currency.Default
+	}
+
+	// O histograma avança antes de a transação ser contada, para que a
+	// comparação com histogramStart use o início atual da janela
+	s.advanceHistogram()
+
+	b := s.buckets.bucketFor(second)
+	if second < s.trimFrom {
+		b.trimmed = true
+	}
+	b.add(t, s.groupLabels(t.Labels, second, first))
+	if s.topMax > 0 {
+		if b.top == nil {
+			b.top = newTopK(s.topMax)
+		}
+		b.top.add(t)
+	}
+
+	bin := s.histogram.bin(t.Value)
+	if b.bins == nil {
+		b.bins = make([]int, len(s.histogram.counts))
+	}
+	b.bins[bin]++
+	if second >= s.histogramStart {
+		s.histogram.counts[bin]++
+	}
+	s.meter.mark(s.provider.Now(), t.Value.Float64())
+
+	if !b.trimmed {
+		s.stored++
+		if t.ID != "" {
+			s.index[t.ID] = transactionRef{second: second, position: len(b.transactions) - 1}
+		}
+		s.trimTransactions()
+	}
 
 	s.logger.Info("transação adicionada às estatísticas",
 		"valor", t.Value,
+		"moeda", t.Currency,
 		"dataHora", t.Timestamp,
 	)
+
+	return true, !b.trimmed
+}
+
+// trimTransactions descarta as transações individuais dos segundos mais
+// antigos enquanto o total armazenado exceder maxTransactions. Os agregados
+// dos buckets são mantidos; apenas a consulta por id, a exportação e as
+// estatísticas filtradas deixam de considerar as transações descartadas.
+// Deve ser chamada com o lock de escrita adquirido.
+func (s *StatisticsService) trimTransactions() {
+	if s.maxTransactions <= 0 || s.stored <= s.maxTransactions {
+		return
+	}
+
+	first, last := bucketRange(s.retention.GetWindow())
+	for second := max(first, s.trimFrom); s.stored > s.maxTransactions && second <= last; second++ {
+		if b, ok := s.buckets.get(second); ok && !b.trimmed {
+			for _, t := range b.transactions {
+				delete(s.index, t.ID)
+			}
+			s.stored -= len(b.transactions)
+			b.transactions = nil
+			b.trimmed = true
+		}
+		s.trimFrom = second + 1
+	}
+
+	s.logger.Info("transações antigas descartadas pelo limite de armazenamento",
+		"limite", s.maxTransactions,
+		"aparadoAte", time.Unix(s.trimFrom, 0),
+	)
+}
+
+// addToPeriods inclui a transação nos períodos de calendário de cada fuso
+// horário, descartando os períodos expirados sempre que um novo é criado.
+// Deve ser chamada com o lock de escrita adquirido.
+func (s *StatisticsService) addToPeriods(t models.Transaction) {
+	cutoff := s.provider.Now().Add(-s.periodRetention)
+	if t.Timestamp.Before(cutoff) {
+		return
+	}
+
+	for _, aggregators := range s.periods {
+		for _, p := range aggregators {
+			if p.add(t.Timestamp, t.Value) {
+				p.prune(cutoff)
+			}
+		}
+	}
+}
+
+// resetPeriods cria agregadores vazios para cada fuso horário e
+// granularidade. Deve ser chamada com o lock de escrita adquirido.
+func (s *StatisticsService) resetPeriods() {
+	s.periods = make(map[string]map[string]*periodAggregator, len(s.periodTimezones))
+	for _, name := range s.periodTimezones {
+		location, _ := time.LoadLocation(name)
+		aggregators := make(map[string]*periodAggregator, len(periodGranularities))
+		for _, granularity := range periodGranularities {
+			aggregators[granularity] = newPeriodAggregator(granularity, location)
+		}
+		s.periods[name] = aggregators
+	}
+}
+
+// groupLabels retorna os rótulos de uma transação do segundo informado
+// usados no agrupamento. Apenas os primeiros labelMaxNames rótulos são
+// agrupados, e cada um tem no máximo labelMaxValues valores com grupo
+// próprio; os demais valores são agregados no grupo handlers.OtherGroup.
+// first é o início da retenção, usado para expirar os valores antigos. Deve
+// ser chamada com o lock de escrita adquirido.
+func (s *StatisticsService) groupLabels(labels map[string]string, second, first int64) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	s.expireLabels(first)
+
+	grouped := make(map[string]string, len(labels))
+	for name, value := range labels {
+		values, ok := s.labelValues[name]
+		if !ok {
+			if s.labelMaxNames > 0 && len(s.labelValues) >= s.labelMaxNames {
+				continue
+			}
+			values = make(map[string]int64)
+			s.labelValues[name] = values
+		}
+
+		last, ok := values[value]
+		if !ok && len(values) >= s.labelMaxValues {
+			grouped[name] = handlers.OtherGroup
+			continue
+		}
+		values[value] = max(last, second)
+		grouped[name] = value
+	}
+
+	return grouped
+}
+
+// expireLabels descarta os valores de rótulo não vistos desde o início da
+// retenção, e os rótulos que ficam sem valores, liberando suas vagas. Os
+// buckets retidos não contêm esses valores. Deve ser chamada com o lock de
+// escrita adquirido.
+func (s *StatisticsService) expireLabels(first int64) {
+	if first <= s.labelsExpiredAt {
+		return
+	}
+	s.labelsExpiredAt = first
+
+	for name, values := range s.labelValues {
+		for value, last := range values {
+			if last < first {
+				delete(values, value)
+			}
+		}
+		if len(values) == 0 {
+			delete(s.labelValues, name)
+		}
+	}
+}
+
+// labelGroup retorna o grupo de um valor de rótulo já registrado: o próprio
+// valor, ou handlers.OtherGroup quando ele excedeu a cardinalidade máxima.
+// Retorna vazio para um rótulo que não é agrupado. Deve ser chamada com o
+// lock adquirido.
+func (s *StatisticsService) labelGroup(name, value string) string {
+	values, ok := s.labelValues[name]
+	if !ok {
+		return ""
+	}
+	if _, ok := values[value]; !ok {
+		return handlers.OtherGroup
+	}
+	return value
 }
 
 // GetStatistics retorna as estatísticas das transações dentro da janela de tempo
 func (s *StatisticsService) GetStatistics() (*handlers.StatisticsResponse, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.QueryStatistics(handlers.StatisticsQuery{})
+}
+
+// QueryStatistics retorna as estatísticas da janela solicitada (ou da janela
+// padrão), incluindo as métricas de distribuição quando percentis forem
+// solicitados. As estatísticas podem ser restritas a uma moeda e agrupadas
+// por moeda, por tipo ou pelos valores de um rótulo.
+func (s *StatisticsService) QueryStatistics(query handlers.StatisticsQuery) (*handlers.StatisticsResponse, error) {
+	window := s.window
+	if query.Window > 0 {
+		window = utils.NewSlidingWindow(query.Window, s.provider)
+	}
 
-	s.cleanOldTransactions()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if len(s.transactions) == 0 {
-		return &handlers.StatisticsResponse{
-			Count: 0,
-			Sum:   0,
-			Avg:   0,
-			Min:   0,
-			Max:   0,
-		}, nil
+	// Sem filtros, os agregados dos buckets são combinados diretamente. Os
+	// filtros exigem percorrer as transações da janela.
+	filtered := !query.Filter.Empty()
+	sel := newSelection(query)
+
+	// Os buckets aparados mantêm apenas os agregados, então o filtro não
+	// alcança as transações desses segundos
+	partial := false
+
+	w := window.GetWindow()
+	first, last := bucketRange(w)
+	for second := first; second <= last; second++ {
+		b, ok := s.buckets.get(second)
+		if !ok {
+			continue
+		}
+
+		if filtered {
+			partial = partial || b.trimmed
+			sel.scanBucket(b, s.labelGroup)
+		} else {
+			sel.mergeBucket(b)
+			if sel.needsSketchScan() {
+				sel.sketchBucket(b, s.labelGroup)
+			}
+		}
 	}
 
-	stats := s.calculateStatistics(s.transactions)
+	stats := sel.total.response(s.rounding, query.Percentiles)
+	stats.Currency = query.Currency
+	if filtered {
+		stats.Filters = &handlers.AppliedFilter{TransactionFilter: query.Filter, Partial: partial}
+		if covered := max(first, s.trimFrom); partial && covered <= last {
+			stats.Filters.Coverage = &handlers.FilterCoverage{Start: time.Unix(covered, 0), End: w.End}
+		}
+	}
+	if groups := sel.groups; groups != nil {
+		stats.Groups = make(map[string]*handlers.StatisticsResponse, len(groups))
+		for code, g := range groups {
+			stats.Groups[code] = g.response(s.rounding, query.Percentiles)
+		}
+		if query.GroupBy == handlers.GroupByCurrency && s.rates != nil {
+			stats.Consolidated = s.consolidate(groups)
+		}
+		if query.GroupBy == handlers.GroupByType {
+			stats.Balance = s.balance(groups)
+		}
+	}
 
 	s.logger.Info("estatísticas calculadas",
 		"count", stats.Count,
@@ -76,57 +458,394 @@ func (s *StatisticsService) GetStatistics() (*handlers.StatisticsResponse, error
 	return stats, nil
 }
 
-// DeleteTransactions remove todas as transações
-func (s *StatisticsService) DeleteTransactions() {
+// groupName retorna o nome do grupo de uma célula na dimensão informada
+func groupName(dimension string, key cellKey) string {
+	if dimension == handlers.GroupByType {
+		if key.kind == "" {
+			return handlers.UntypedGroup
+		}
+		return key.kind
+	}
+	return key.currency
+}
+
+// balance retorna o saldo (créditos menos débitos) dos grupos por tipo.
+// Transações sem tipo não entram no saldo.
+func (s *StatisticsService) balance(groups map[string]*group) *decimal.Decimal {
+	var credits, debits decimal.Decimal
+	if g, ok := groups[models.TypeCredit]; ok {
+		credits = g.sum
+	}
+	if g, ok := groups[models.TypeDebit]; ok {
+		debits = g.sum
+	}
+
+	balance := credits.Sub(debits).Round(s.rounding.scale, s.rounding.mode)
+	return &balance
+}
+
+// consolidate converte a soma de cada moeda para a moeda base da tabela de
+// cotações. Deve ser chamada com o lock adquirido.
+func (s *StatisticsService) consolidate(groups map[string]*group) *handlers.ConsolidatedTotal {
+	consolidated := &handlers.ConsolidatedTotal{Currency: s.rates.Base()}
+
+	var sum decimal.Decimal
+	for code, g := range groups {
+		rate, ok := s.rates.Rate(code)
+		if !ok {
+			consolidated.Unconverted = append(consolidated.Unconverted, code)
+			continue
+		}
+		sum = sum.Add(g.sum.Mul(rate))
+		consolidated.Count += g.count
+	}
+	sort.Strings(consolidated.Unconverted)
+
+	consolidated.Sum = sum.Round(s.rounding.scale, s.rounding.mode)
+	if consolidated.Count > 0 {
+		consolidated.Avg = sum.QuoInt(int64(consolidated.Count), s.rounding.scale, s.rounding.mode)
+	}
+
+	return consolidated
+}
+
+// GetSeries retorna as estatísticas agregadas em intervalos de tamanho step
+// que cobrem a janela informada. Os intervalos são alinhados a múltiplos de
+// step desde a época Unix, e intervalos sem transações são retornados zerados.
+// Cada intervalo percorre apenas os segundos do período de retenção, de modo
+// que o custo não depende do tamanho da janela nem do passo.
+func (s *StatisticsService) GetSeries(window utils.TimeWindow, step time.Duration) ([]handlers.SeriesPoint, error) {
+	stepSeconds := int64(step / time.Second)
+	start := window.Start.Unix()
+	start -= ((start % stepSeconds) + stepSeconds) % stepSeconds
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	first, last := bucketRange(s.retention.GetWindow())
+	points := make([]handlers.SeriesPoint, 0)
+	for pointStart := start; time.Unix(pointStart, 0).Before(window.End); pointStart += stepSeconds {
+		var total aggregate
+		for second := max(pointStart, first); second < pointStart+stepSeconds && second <= last; second++ {
+			if b, ok := s.buckets.get(second); ok {
+				total.merge(&b.group.aggregate)
+			}
+		}
+
+		points = append(points, handlers.SeriesPoint{
+			Start:              time.Unix(pointStart, 0).UTC(),
+			End:                time.Unix(pointStart+stepSeconds, 0).UTC(),
+			StatisticsResponse: *total.toResponse(s.rounding),
+		})
+	}
+
+	return points, nil
+}
+
+// GetTransaction retorna uma transação ainda dentro do período de retenção
+func (s *StatisticsService) GetTransaction(id string) (models.Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ref, ok := s.index[id]
+	if !ok {
+		return models.Transaction{}, models.ErrTransactionNotFound
+	}
+
+	// Buckets expirados só são descartados quando sua posição é reutilizada
+	first, _ := bucketRange(s.retention.GetWindow())
+	b, ok := s.buckets.get(ref.second)
+	if !ok || ref.second < first {
+		return models.Transaction{}, models.ErrTransactionNotFound
+	}
+
+	return b.transactions[ref.position], nil
+}
+
+// EachTransaction percorre em ordem cronológica as transações ainda dentro do
+// período de retenção, interrompendo no primeiro erro retornado por fn. O lock
+// é adquirido a cada segundo, de modo que fn pode ser lenta (ex.: escrita em
+// uma conexão) sem bloquear a inclusão de novas transações.
+func (s *StatisticsService) EachTransaction(fn func(models.Transaction) error) error {
+	first, last := bucketRange(s.retention.GetWindow())
+
+	var transactions []models.Transaction
+	for second := first; second <= last; second++ {
+		s.mu.RLock()
+		if b, ok := s.buckets.get(second); ok {
+			transactions = append(transactions[:0], b.transactions...)
+		} else {
+			transactions = transactions[:0]
+		}
+		s.mu.RUnlock()
+
+		for _, t := range transactions {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// TopTransactions retorna as n transações de maior valor da janela
+// solicitada (ou da janela padrão), em ordem decrescente de valor. Os
+// rankings de cada segundo da janela são combinados, de modo que transações
+// que saem da janela deixam de ser consideradas.
+func (s *StatisticsService) TopTransactions(n int, windowDuration time.Duration) ([]handlers.TopTransaction, error) {
+	window := s.window
+	if windowDuration > 0 {
+		window = utils.NewSlidingWindow(windowDuration, s.provider)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	top := newTopK(n)
+	first, last := bucketRange(window.GetWindow())
+	for second := first; second <= last; second++ {
+		if b, ok := s.buckets.get(second); ok && b.top != nil {
+			top.merge(b.top)
+		}
+	}
+
+	transactions := top.sorted()
+	result := make([]handlers.TopTransaction, len(transactions))
+	for i, t := range transactions {
+		result[i] = handlers.TopTransaction{
+			ID:        t.ID,
+			Value:     t.Value,
+			Currency:  t.Currency,
+			Timestamp: t.Timestamp,
+			Labels:    t.Labels,
+		}
+	}
+
+	return result, nil
+}
+
+// TopLimit retorna a maior quantidade de transações aceita em TopTransactions
+func (s *StatisticsService) TopLimit() int {
+	return s.topMax
+}
+
+// Histogram retorna a quantidade de transações da janela padrão em cada
+// faixa de valor. As contagens são mantidas incrementalmente, sem percorrer
+// as transações da janela.
+func (s *StatisticsService) Histogram() (*handlers.HistogramResponse, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.transactions = make([]models.Transaction, 0)
-	s.logger.Info("todas as transações foram removidas das estatísticas")
+	s.advanceHistogram()
+	return s.histogram.response(), nil
 }
 
-// cleanOldTransactions remove transações fora da janela de tempo
-func (s *StatisticsService) cleanOldTransactions() {
-	validTransactions := make([]models.Transaction, 0)
-	for _, t := range s.transactions {
-		if s.window.IsInWindow(t.Timestamp) {
-			validTransactions = append(validTransactions, t)
+// Throughput retorna a taxa de transações e de valor por segundo na janela
+// padrão e as médias móveis de 1, 5 e 15 minutos da chegada de transações,
+// calculadas pelo relógio do TimeProvider
+func (s *StatisticsService) Throughput() (*handlers.ThroughputResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := s.windowAggregate(s.window.GetWindow())
+	seconds := s.window.Duration().Seconds()
+	s.meter.tick(s.provider.Now())
+
+	return &handlers.ThroughputResponse{
+		TPS:            float64(total.count) / seconds,
+		ValuePerSecond: total.sum.QuoInt(int64(seconds), s.rounding.scale, s.rounding.mode),
+		TPSAverages:    rateAverages(s.meter.counts),
+		ValueAverages:  rateAverages(s.meter.values),
+	}, nil
+}
+
+// Compare retorna as estatísticas da janela padrão, as da janela de mesma
+// duração imediatamente anterior e a variação de cada métrica entre elas
+func (s *StatisticsService) Compare() (*handlers.ComparisonResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := s.windowAggregate(s.window.GetWindow())
+	previous := s.windowAggregate(s.window.Previous())
+
+	comparison := &handlers.ComparisonResponse{
+		Current:  current.toResponse(s.rounding),
+		Previous: previous.toResponse(s.rounding),
+	}
+	comparison.Deltas = handlers.ComparisonDeltas{
+		Count: metricDelta(decimal.NewFromInt(int64(current.count)), decimal.NewFromInt(int64(previous.count))),
+		Sum:   metricDelta(comparison.Current.Sum, comparison.Previous.Sum),
+		Avg:   metricDelta(comparison.Current.Avg, comparison.Previous.Avg),
+		Min:   metricDelta(comparison.Current.Min, comparison.Previous.Min),
+		Max:   metricDelta(comparison.Current.Max, comparison.Previous.Max),
+	}
+
+	return comparison, nil
+}
+
+// GetPeriods retorna as estatísticas de cada período de calendário da
+// granularidade e do fuso horário solicitados que se sobrepõe ao intervalo.
+// Períodos sem transações são retornados zerados.
+func (s *StatisticsService) GetPeriods(query handlers.PeriodQuery) ([]handlers.SeriesPoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.periods[query.Timezone][query.Granularity]
+	if !ok {
+		return nil, fmt.Errorf("agregação por %s no fuso %s não configurada", query.Granularity, query.Timezone)
+	}
+
+	var points []handlers.SeriesPoint
+	for start := query.Window.Start; start.Before(query.Window.End); {
+		period, err := utils.CalendarPeriod(start, query.Granularity, p.location)
+		if err != nil {
+			return nil, err
 		}
+		// Garante o avanço mesmo que um período não termine depois do seu início
+		if !period.End.After(start) {
+			break
+		}
+
+		stats := &handlers.StatisticsResponse{}
+		if a, ok := p.get(period.Start); ok {
+			stats = a.toResponse(s.rounding)
+		}
+		points = append(points, handlers.SeriesPoint{
+			Start:              period.Start,
+			End:                period.End,
+			StatisticsResponse: *stats,
+		})
+		start = period.End
 	}
 
-	if len(s.transactions) != len(validTransactions) {
-		s.logger.Info("transações antigas removidas",
-			"removidas", len(s.transactions)-len(validTransactions),
-		)
+	return points, nil
+}
+
+// PeriodTimezones retorna os fusos horários com agregação por período
+func (s *StatisticsService) PeriodTimezones() []string {
+	return s.periodTimezones
+}
+
+// PeriodRetention retorna por quanto tempo os períodos de calendário são mantidos
+func (s *StatisticsService) PeriodRetention() time.Duration {
+	return s.periodRetention
+}
+
+// windowAggregate combina os agregados dos buckets da janela. Deve ser
+// chamada com o lock adquirido.
+func (s *StatisticsService) windowAggregate(window utils.TimeWindow) aggregate {
+	var total aggregate
+	first, last := bucketRange(window)
+	for second := first; second <= last; second++ {
+		if b, ok := s.buckets.get(second); ok {
+			total.merge(&b.aggregate)
+		}
 	}
+	return total
+}
 
-	s.transactions = validTransactions
+// metricDelta calcula a variação absoluta e percentual de uma métrica. A
+// variação percentual é nil quando o valor anterior é zero.
+func metricDelta(current, previous decimal.Decimal) handlers.MetricDelta {
+	delta := handlers.MetricDelta{Absolute: current.Sub(previous)}
+	if previous.Sign() != 0 {
+		percent := delta.Absolute.Float64() / previous.Float64() * 100
+		delta.Percent = &percent
+	}
+	return delta
 }
 
-// calculateStatistics calcula as estatísticas para um conjunto de transações
-func (s *StatisticsService) calculateStatistics(transactions []models.Transaction) *handlers.StatisticsResponse {
-	var sum float64
-	min := math.MaxFloat64
-	max := -math.MaxFloat64
+// rateAverages converte as médias móveis do medidor para a resposta da API
+func rateAverages(averages [len(meterPeriods)]ewma) handlers.RateAverages {
+	return handlers.RateAverages{
+		M1:  averages[0].rate,
+		M5:  averages[1].rate,
+		M15: averages[2].rate,
+	}
+}
 
-	for _, t := range transactions {
-		sum += t.Value
-		if t.Value < min {
-			min = t.Value
+// advanceHistogram remove do histograma os buckets que saíram da janela
+// padrão desde a última atualização. Deve ser chamada com o lock de escrita
+// adquirido.
+func (s *StatisticsService) advanceHistogram() {
+	first, _ := bucketRange(s.window.GetWindow())
+	if first <= s.histogramStart {
+		return
+	}
+
+	if first-s.histogramStart < int64(len(s.buckets.buckets)) {
+		for second := s.histogramStart; second < first; second++ {
+			if b, ok := s.buckets.get(second); ok {
+				s.histogram.subtract(b.bins)
+			}
 		}
-		if t.Value > max {
-			max = t.Value
+	} else {
+		// Após um longo período sem atualização é mais barato percorrer o buffer
+		for i := range s.buckets.buckets {
+			b := &s.buckets.buckets[i]
+			if b.count > 0 && b.second >= s.histogramStart && b.second < first {
+				s.histogram.subtract(b.bins)
+			}
 		}
 	}
 
-	count := len(transactions)
-	avg := sum / float64(count)
+	s.histogramStart = first
+}
+
+// Retention retorna a maior janela de tempo que pode ser consultada
+func (s *StatisticsService) Retention() time.Duration {
+	return s.retention.Duration()
+}
+
+// Now retorna o momento atual segundo o TimeProvider do serviço
+func (s *StatisticsService) Now() time.Time {
+	return s.provider.Now()
+}
+
+// DeleteTransactions remove todas as transações
+func (s *StatisticsService) DeleteTransactions() {
+	s.mu.Lock()
+	s.buckets.reset()
+	s.index = make(map[string]transactionRef)
+	s.labelValues = make(map[string]map[string]int64)
+	s.labelsExpiredAt = 0
+	s.stored = 0
+	s.trimFrom = 0
+	s.meter = newMeter(s.provider.Now())
+	s.resetPeriods()
+	s.mu.Unlock()
+
+	s.logger.Info("todas as transações foram removidas das estatísticas")
+	s.notifyChange()
+}
+
+// evictBucket remove do índice as transações de um bucket descartado, e do
+// histograma quando o bucket ainda era contado na janela
+func (s *StatisticsService) evictBucket(b *bucket) {
+	for _, t := range b.transactions {
+		delete(s.index, t.ID)
+	}
+	s.stored -= len(b.transactions)
+	if b.second >= s.histogramStart {
+		s.histogram.subtract(b.bins)
+	}
+}
+
+// notifyChange avisa os listeners registrados de que a janela foi alterada
+func (s *StatisticsService) notifyChange() {
+	s.mu.RLock()
+	listeners := s.listeners
+	s.mu.RUnlock()
 
-	return &handlers.StatisticsResponse{
-		Count: count,
-		Sum:   sum,
-		Avg:   avg,
-		Min:   min,
-		Max:   max,
+	for _, listener := range listeners {
+		listener()
 	}
 }
+
+// bucketRange retorna o primeiro e o último segundo cobertos por uma janela.
+// A granularidade é de um segundo: o segundo parcial no início da janela é
+// descartado para que uma janela de N segundos contenha exatamente N buckets.
+func bucketRange(w utils.TimeWindow) (int64, int64) {
+	return w.Start.Unix() + 1, w.End.Unix()
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"container/heap"
+	"sort"
+
+	"api-itau/internal/models"
+)
+
+// topK mantém as k transações de maior valor em um min-heap: a menor delas
+// fica na raiz e é substituída quando chega uma transação maior. A inclusão
+// é O(log k) e a memória é limitada a k transações.
+type topK struct {
+	k     int
+	items transactionHeap
+}
+
+// newTopK cria um topK vazio com capacidade k
+func newTopK(k int) *topK {
+	return &topK{k: k}
+}
+
+// add inclui a transação se ela estiver entre as k maiores
+func (t *topK) add(tx models.Transaction) {
+	if len(t.items) < t.k {
+		heap.Push(&t.items, tx)
+		return
+	}
+	if len(t.items) > 0 && greater(tx, t.items[0]) {
+		t.items[0] = tx
+		heap.Fix(&t.items, 0)
+	}
+}
+
+// merge inclui as transações de outro topK
+func (t *topK) merge(other *topK) {
+	for _, tx := range other.items {
+		t.add(tx)
+	}
+}
+
+// sorted retorna as transações em ordem decrescente de valor
+func (t *topK) sorted() []models.Transaction {
+	result := make([]models.Transaction, len(t.items))
+	copy(result, t.items)
+	sort.Slice(result, func(i, j int) bool {
+		return greater(result[i], result[j])
+	})
+	return result
+}
+
+// greater indica se a transação a precede b no ranking: maior valor e, em
+// caso de empate, a mais antiga
+func greater(a, b models.Transaction) bool {
+	if cmp := a.Value.Cmp(b.Value); cmp != 0 {
+		return cmp > 0
+	}
+	return a.Timestamp.Before(b.Timestamp)
+}
+
+// transactionHeap implementa heap.Interface com a transação de menor
+// posição no ranking na raiz
+type transactionHeap []models.Transaction
+
+func (h transactionHeap) Len() int           { return len(h) }
+func (h transactionHeap) Less(i, j int) bool { return greater(h[j], h[i]) }
+func (h transactionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *transactionHeap) Push(x any) {
+	*h = append(*h, x.(models.Transaction))
+}
+
+func (h *transactionHeap) Pop() any {
+	old := *h
+	n := len(old)
+	tx := old[n-1]
+	*h = old[:n-1]
+	return tx
+}
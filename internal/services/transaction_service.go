@@ -1,6 +1,8 @@
 package services
 
 import (
+	"sync"
+
 	"api-itau/internal/models"
 	"api-itau/pkg/logger"
 )
@@ -8,6 +10,8 @@ import (
 // TransactionService implementa a interface handlers.TransactionService
 type TransactionService struct {
 	statsService *StatisticsService
+	listeners    []func(models.Transaction)
+	mu           sync.RWMutex
 	logger       logger.Logger
 }
 
@@ -19,19 +23,68 @@ func NewTransactionService(statsService *StatisticsService, logger logger.Logger
 	}
 }
 
-// AddTransaction adiciona uma nova transação
-func (s *TransactionService) AddTransaction(t models.Transaction) error {
+// OnTransaction registra uma função chamada para cada transação aceita.
+// As funções são chamadas de forma síncrona e não devem bloquear.
+func (s *TransactionService) OnTransaction(listener func(models.Transaction)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// AddTransaction adiciona uma nova transação, retornando se ela foi
+// armazenada e pode ser consultada pelo id
+func (s *TransactionService) AddTransaction(t models.Transaction) (bool, error) {
 	// Adiciona a transação ao serviço de estatísticas
-	s.statsService.AddTransaction(t)
+	stored := s.statsService.AddTransaction(t)
 
 	s.logger.Info("transação adicionada com sucesso",
 		"valor", t.Value,
 		"dataHora", t.Timestamp,
+		"armazenada", stored,
+	)
+
+	s.notifyTransaction(t)
+
+	return stored, nil
+}
+
+// AddTransactions adiciona um lote de transações de uma só vez
+func (s *TransactionService) AddTransactions(transactions []models.Transaction) error {
+	s.statsService.AddTransactions(transactions)
+
+	s.logger.Info("lote de transações adicionado com sucesso",
+		"quantidade", len(transactions),
 	)
 
+	for _, t := range transactions {
+		s.notifyTransaction(t)
+	}
+
 	return nil
 }
 
+// notifyTransaction avisa os listeners registrados sobre uma transação aceita
+func (s *TransactionService) notifyTransaction(t models.Transaction) {
+	s.mu.RLock()
+	listeners := s.listeners
+	s.mu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(t)
+	}
+}
+
+// GetTransaction retorna uma transação pelo id
+func (s *TransactionService) GetTransaction(id string) (models.Transaction, error) {
+	return s.statsService.GetTransaction(id)
+}
+
+// EachTransaction percorre as transações ainda dentro do período de retenção
+func (s *TransactionService) EachTransaction(fn func(models.Transaction) error) error {
+	return s.statsService.EachTransaction(fn)
+}
+
 // DeleteTransactions remove todas as transações
 func (s *TransactionService) DeleteTransactions() error {
 	// Remove as transações do serviço de estatísticas
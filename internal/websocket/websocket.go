@@ -0,0 +1,295 @@
+// Package websocket implementa o lado servidor do protocolo WebSocket
+// (RFC 6455) sobre a biblioteca padrão: handshake, framing, fragmentação
+// e frames de controle.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// acceptGUID é o valor fixo concatenado à chave do cliente no handshake
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcode identifica o tipo de um frame
+type Opcode byte
+
+const (
+	OpContinuation Opcode = 0x0
+	OpText         Opcode = 0x1
+	OpBinary       Opcode = 0x2
+	OpClose        Opcode = 0x8
+	OpPing         Opcode = 0x9
+	OpPong         Opcode = 0xA
+)
+
+// Códigos de fechamento usados pelo servidor
+const (
+	CloseNormal        = 1000
+	CloseGoingAway     = 1001
+	CloseProtocolError = 1002
+	CloseTooBig        = 1009
+	CloseTryAgainLater = 1013
+)
+
+var (
+	// ErrBadHandshake indica uma requisição que não é um upgrade WebSocket válido
+	ErrBadHandshake = errors.New("websocket: handshake inválido")
+	// ErrClosed indica que a conexão foi fechada
+	ErrClosed = errors.New("websocket: conexão fechada")
+	// ErrProtocol indica um frame que viola o protocolo
+	ErrProtocol = errors.New("websocket: erro de protocolo")
+	// ErrMessageTooBig indica uma mensagem maior que o limite configurado
+	ErrMessageTooBig = errors.New("websocket: mensagem muito grande")
+)
+
+// Conn representa uma conexão WebSocket do lado servidor.
+// Leituras devem ser feitas por uma única goroutine; escritas são seguras
+// para uso concorrente.
+type Conn struct {
+	conn           net.Conn
+	reader         *bufio.Reader
+	writeMu        sync.Mutex
+	maxMessageSize int64
+	closeOnce      sync.Once
+}
+
+// Upgrade valida o handshake, assume o controle da conexão TCP e responde
+// com 101 Switching Protocols. Em caso de ErrBadHandshake nada foi escrito
+// na resposta, e o chamador deve responder com o erro apropriado.
+func Upgrade(w http.ResponseWriter, r *http.Request, maxMessageSize int64) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, ErrBadHandshake
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, ErrBadHandshake
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: erro ao assumir a conexão: %w", err)
+	}
+
+	// Remove os deadlines herdados de ReadTimeout/WriteTimeout do servidor
+	if err := netConn.SetDeadline(time.Time{}); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: erro ao configurar deadline: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := rw.WriteString(response); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: erro ao enviar handshake: %w", err)
+	}
+	if err := rw.Flush(); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: erro ao enviar handshake: %w", err)
+	}
+
+	return &Conn{
+		conn:           netConn,
+		reader:         rw.Reader,
+		maxMessageSize: maxMessageSize,
+	}, nil
+}
+
+// acceptKey calcula o valor de Sec-WebSocket-Accept para a chave do cliente
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContains verifica se um header com valores separados por vírgula
+// contém o token informado, sem diferenciar maiúsculas de minúsculas
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadMessage lê a próxima mensagem de dados, remontando fragmentos.
+// Pings são respondidos automaticamente e pongs são descartados. Um frame
+// de fechamento é respondido e resulta em ErrClosed.
+func (c *Conn) ReadMessage() (Opcode, []byte, error) {
+	var messageType Opcode
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			switch {
+			case errors.Is(err, ErrProtocol):
+				c.Close(CloseProtocolError, "")
+			case errors.Is(err, ErrMessageTooBig):
+				c.Close(CloseTooBig, "")
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case OpPing:
+			if err := c.WriteMessage(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			c.Close(CloseNormal, "")
+			return 0, nil, ErrClosed
+		case OpText, OpBinary:
+			if messageType != 0 {
+				c.Close(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+			messageType = opcode
+		case OpContinuation:
+			if messageType == 0 {
+				c.Close(CloseProtocolError, "")
+				return 0, nil, ErrProtocol
+			}
+		default:
+			c.Close(CloseProtocolError, "")
+			return 0, nil, ErrProtocol
+		}
+
+		if int64(len(message)+len(payload)) > c.maxMessageSize {
+			c.Close(CloseTooBig, "")
+			return 0, nil, ErrMessageTooBig
+		}
+		message = append(message, payload...)
+
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame lê um único frame do cliente, removendo a máscara do payload
+func (c *Conn) readFrame() (bool, Opcode, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := Opcode(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+
+	// Bits reservados exigem extensões, que não são negociadas
+	if header[0]&0x70 != 0 || !masked {
+		return false, 0, nil, ErrProtocol
+	}
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.reader, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+
+	if opcode >= OpClose && (!fin || length > 125) {
+		return false, 0, nil, ErrProtocol
+	}
+	if length < 0 || length > c.maxMessageSize {
+		return false, 0, nil, ErrMessageTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage envia uma mensagem em um único frame, sem máscara
+func (c *Conn) WriteMessage(opcode Opcode, payload []byte) error {
+	header := make([]byte, 0, 10)
+	header = append(header, 0x80|byte(opcode))
+
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetWriteDeadline define o prazo para as próximas escritas
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+// Close envia um frame de fechamento com o código informado e fecha a
+// conexão TCP. Chamadas subsequentes não têm efeito.
+func (c *Conn) Close(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+
+		c.conn.SetWriteDeadline(time.Now().Add(time.Second))
+		c.WriteMessage(OpClose, payload)
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// RemoteAddr retorna o endereço do cliente
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
@@ -0,0 +1,95 @@
+// Package currency valida códigos de moeda ISO 4217 e converte valores entre
+// moedas a partir de uma tabela de cotações.
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"api-itau/pkg/decimal"
+)
+
+// Default é a moeda das transações que não informam uma moeda
+const Default = "BRL"
+
+// ErrInvalidCode indica que o texto não é um código de moeda ISO 4217
+var ErrInvalidCode = errors.New("moeda deve ser um código ISO 4217 em vigor")
+
+// Normalize converte um código de moeda para letras maiúsculas, validando
+// que ele é um código ISO 4217 em vigor
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !codes[code] {
+		return "", ErrInvalidCode
+	}
+	return code, nil
+}
+
+// Rates fornece a cotação de cada moeda em relação a uma moeda base
+type Rates interface {
+	// Base retorna a moeda na qual os valores convertidos são expressos
+	Base() string
+	// Rate retorna quantas unidades da moeda base valem uma unidade da moeda
+	// informada, e false quando não há cotação para ela
+	Rate(code string) (decimal.Decimal, bool)
+}
+
+// StaticRates é uma tabela de cotações fixa, carregada da configuração
+type StaticRates struct {
+	base  string
+	rates map[string]decimal.Decimal
+}
+
+// NewStaticRates cria uma tabela de cotações em relação à moeda base.
+// A cotação da própria moeda base é sempre 1.
+func NewStaticRates(base string, rates map[string]decimal.Decimal) *StaticRates {
+	table := make(map[string]decimal.Decimal, len(rates)+1)
+	for code, rate := range rates {
+		table[code] = rate
+	}
+	table[base] = decimal.NewFromInt(1)
+
+	return &StaticRates{base: base, rates: table}
+}
+
+// Base retorna a moeda base da tabela
+func (r *StaticRates) Base() string {
+	return r.base
+}
+
+// Rate retorna a cotação da moeda em relação à moeda base
+func (r *StaticRates) Rate(code string) (decimal.Decimal, bool) {
+	rate, ok := r.rates[code]
+	return rate, ok
+}
+
+// ParseRates interpreta uma tabela de cotações no formato "USD=5.10,EUR=5.50",
+// em que cada cotação é o valor de uma unidade da moeda na moeda base
+func ParseRates(raw string) (map[string]decimal.Decimal, error) {
+	rates := make(map[string]decimal.Decimal)
+	if strings.TrimSpace(raw) == "" {
+		return rates, nil
+	}
+
+	for _, entry := range strings.Split(raw, ",") {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("cotação inválida: %q", entry)
+		}
+
+		code, err := Normalize(name)
+		if err != nil {
+			return nil, fmt.Errorf("cotação inválida: %q: %w", entry, err)
+		}
+
+		rate, err := decimal.Parse(strings.TrimSpace(value))
+		if err != nil || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("cotação inválida: %q", entry)
+		}
+
+		rates[code] = rate
+	}
+
+	return rates, nil
+}
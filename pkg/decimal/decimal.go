@@ -0,0 +1,347 @@
+// Package decimal implementa um número decimal de precisão arbitrária para
+// valores monetários. Os valores são exatos: a soma de centavos não acumula
+// erros de ponto flutuante e a serialização não produz artefatos como
+// 0.30000000000000004.
+package decimal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxDigits limita a quantidade de dígitos inteiros e decimais aceitos na
+// conversão de texto, evitando alocações desproporcionais (ex.: 1e1000000000)
+const maxDigits = 64
+
+// ErrInvalidNumber indica que o texto não é um número decimal válido
+var ErrInvalidNumber = errors.New("número decimal inválido")
+
+// ErrOutOfRange indica que o número excede a precisão suportada
+var ErrOutOfRange = errors.New("número decimal fora do intervalo suportado")
+
+// RoundingMode define como um valor é arredondado para uma quantidade de casas
+type RoundingMode int
+
+const (
+	// HalfEven arredonda empates para o dígito par (arredondamento bancário)
+	HalfEven RoundingMode = iota
+	// HalfUp arredonda empates para longe do zero
+	HalfUp
+)
+
+// ParseRoundingMode converte o nome de um modo de arredondamento
+// (half_even ou half_up)
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	switch name {
+	case "half_even":
+		return HalfEven, nil
+	case "half_up":
+		return HalfUp, nil
+	default:
+		return 0, fmt.Errorf("modo de arredondamento inválido: %q", name)
+	}
+}
+
+// String retorna o nome do modo de arredondamento
+func (m RoundingMode) String() string {
+	if m == HalfUp {
+		return "half_up"
+	}
+	return "half_even"
+}
+
+// Decimal é um número decimal imutável igual a coef × 10^-scale. O valor zero
+// de Decimal representa 0. Os valores são mantidos normalizados, sem zeros à
+// direita na parte decimal, de modo que valores iguais têm a mesma representação.
+type Decimal struct {
+	coef  *big.Int
+	scale int32
+}
+
+// New cria um Decimal igual a coef × 10^-scale
+func New(coef int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(coef), scale)
+}
+
+// NewFromInt cria um Decimal a partir de um inteiro
+func NewFromInt(value int64) Decimal {
+	return New(value, 0)
+}
+
+// Parse converte um número em notação decimal ou científica (ex.: 123.45,
+// -0.5, 1e3), seguindo a gramática de números do JSON, sem perda de precisão
+func Parse(s string) (Decimal, error) {
+	rest := s
+	negative := strings.HasPrefix(rest, "-")
+	if negative {
+		rest = rest[1:]
+	}
+
+	mantissa, exponent := rest, ""
+	if i := strings.IndexAny(rest, "eE"); i >= 0 {
+		mantissa, exponent = rest[:i], rest[i+1:]
+		if exponent == "" {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+		}
+	}
+
+	integer, fraction, hasPoint := strings.Cut(mantissa, ".")
+	if !isDigits(integer) || (hasPoint && !isDigits(fraction)) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+	if len(integer) > 1 && integer[0] == '0' {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+	}
+
+	scale := int64(len(fraction))
+	if exponent != "" {
+		exp, err := strconv.ParseInt(exponent, 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidNumber, s)
+		}
+		scale -= exp
+	}
+
+	digits := strings.TrimLeft(integer+fraction, "0")
+	if len(digits) > maxDigits || scale > maxDigits || int64(len(digits))-scale > maxDigits {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrOutOfRange, s)
+	}
+
+	coef, _ := new(big.Int).SetString(integer+fraction, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	if scale < 0 {
+		coef.Mul(coef, pow10(-scale))
+		scale = 0
+	}
+
+	return newDecimal(coef, int32(scale)), nil
+}
+
+// MustParse é como Parse, mas entra em pânico se o texto for inválido.
+// Destina-se a constantes e testes.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// newDecimal cria um Decimal normalizado, assumindo a posse de coef
+func newDecimal(coef *big.Int, scale int32) Decimal {
+	if coef.Sign() == 0 {
+		return Decimal{}
+	}
+
+	ten := big.NewInt(10)
+	quotient, remainder := new(big.Int), new(big.Int)
+	for scale > 0 {
+		quotient.QuoRem(coef, ten, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		coef.Set(quotient)
+		scale--
+	}
+
+	return Decimal{coef: coef, scale: scale}
+}
+
+// int retorna o coeficiente, tratando o valor zero de Decimal
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// Sign retorna -1, 0 ou +1 conforme o sinal do valor
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero indica se o valor é zero
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Scale retorna a quantidade de casas decimais do valor normalizado
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Cmp compara d com other, retornando -1, 0 ou +1
+func (d Decimal) Cmp(other Decimal) int {
+	a, b := align(d, other)
+	return a.Cmp(b)
+}
+
+// Add retorna d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b := align(d, other)
+	return newDecimal(new(big.Int).Add(a, b), max(d.scale, other.scale))
+}
+
+// Sub retorna d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b := align(d, other)
+	return newDecimal(new(big.Int).Sub(a, b), max(d.scale, other.scale))
+}
+
+// Mul retorna d × other
+func (d Decimal) Mul(other Decimal) Decimal {
+	return newDecimal(new(big.Int).Mul(d.int(), other.int()), d.scale+other.scale)
+}
+
+// QuoInt retorna d / n arredondado para scale casas decimais
+func (d Decimal) QuoInt(n int64, scale int32, mode RoundingMode) Decimal {
+	if n == 0 {
+		panic("decimal: divisão por zero")
+	}
+
+	numerator := new(big.Int).Mul(d.int(), pow10(int64(scale)))
+	if n < 0 {
+		numerator.Neg(numerator)
+		n = -n
+	}
+	denominator := new(big.Int).Mul(big.NewInt(n), pow10(int64(d.scale)))
+	return newDecimal(quoRound(numerator, denominator, mode), scale)
+}
+
+// Round arredonda d para scale casas decimais. Valores com menos casas são
+// retornados sem alteração.
+func (d Decimal) Round(scale int32, mode RoundingMode) Decimal {
+	if d.scale <= scale {
+		return d
+	}
+	return d.QuoInt(1, scale, mode)
+}
+
+// Float64 retorna a aproximação de ponto flutuante mais próxima do valor
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String retorna o valor em notação decimal, sem expoente
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+
+	if d.scale == 0 {
+		return sign + digits
+	}
+
+	if pad := int(d.scale) - len(digits) + 1; pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - int(d.scale)
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON serializa o valor como um número JSON
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON converte um número JSON sem perda de precisão. Outros tipos
+// JSON resultam em *json.UnmarshalTypeError, como ocorre com float64.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if kind := jsonKind(data); kind != "number" {
+		return &json.UnmarshalTypeError{Value: kind, Type: reflect.TypeOf(d).Elem()}
+	}
+
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// jsonKind descreve o tipo de um valor JSON a partir do primeiro caractere
+func jsonKind(data []byte) string {
+	if len(data) == 0 {
+		return "number"
+	}
+
+	switch data[0] {
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	default:
+		return "number"
+	}
+}
+
+// align retorna os coeficientes de a e b na mesma escala
+func align(a, b Decimal) (*big.Int, *big.Int) {
+	switch {
+	case a.scale < b.scale:
+		return new(big.Int).Mul(a.int(), pow10(int64(b.scale-a.scale))), b.int()
+	case a.scale > b.scale:
+		return a.int(), new(big.Int).Mul(b.int(), pow10(int64(a.scale-b.scale)))
+	default:
+		return a.int(), b.int()
+	}
+}
+
+// quoRound divide numerator por denominator (positivo), arredondando o
+// quociente inteiro conforme o modo informado
+func quoRound(numerator, denominator *big.Int, mode RoundingMode) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+
+	// Compara o dobro do resto com o divisor para identificar empates
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(denominator)
+
+	roundAway := cmp > 0 || (cmp == 0 && (mode == HalfUp || quotient.Bit(0) == 1))
+	if roundAway {
+		quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+	}
+
+	return quotient
+}
+
+// pow10 retorna 10^n
+func pow10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+// isDigits indica se s é uma sequência não vazia de dígitos ASCII
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
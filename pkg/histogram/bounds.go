@@ -0,0 +1,106 @@
+// Package histogram define os limites das faixas de valor do histograma de
+// transações, gerados de forma linear, exponencial ou a partir de uma lista.
+package histogram
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"api-itau/pkg/decimal"
+)
+
+// MaxBounds é a maior quantidade de limites aceita, o que resulta em no
+// máximo MaxBounds+1 faixas
+const MaxBounds = 100
+
+// ErrInvalidBounds indica que os limites são negativos ou não são estritamente crescentes
+var ErrInvalidBounds = errors.New("os limites do histograma devem ser não negativos e estritamente crescentes")
+
+// Linear gera count limites a partir de start, separados por width
+func Linear(start, width decimal.Decimal, count int) []decimal.Decimal {
+	bounds := make([]decimal.Decimal, count)
+	for i := range bounds {
+		bounds[i] = start
+		start = start.Add(width)
+	}
+	return bounds
+}
+
+// Exponential gera count limites a partir de start, cada um factor vezes o anterior
+func Exponential(start, factor decimal.Decimal, count int) []decimal.Decimal {
+	bounds := make([]decimal.Decimal, count)
+	for i := range bounds {
+		bounds[i] = start
+		start = start.Mul(factor)
+	}
+	return bounds
+}
+
+// ParseBounds interpreta a configuração dos limites em um dos formatos
+// "linear:inicio,largura,quantidade", "exponencial:inicio,fator,quantidade"
+// ou "lista:10,50,100". Cada limite é o maior valor incluído na sua faixa.
+func ParseBounds(raw string) ([]decimal.Decimal, error) {
+	kind, params, ok := strings.Cut(strings.TrimSpace(raw), ":")
+	if !ok {
+		return nil, fmt.Errorf("histograma inválido: %q", raw)
+	}
+	kind = strings.ToLower(kind)
+
+	var bounds []decimal.Decimal
+	switch kind {
+	case "linear", "exponencial":
+		fields := strings.Split(params, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("histograma %s deve informar início, passo e quantidade: %q", kind, raw)
+		}
+		start, err := decimal.Parse(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("início do histograma inválido: %q", fields[0])
+		}
+		step, err := decimal.Parse(strings.TrimSpace(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("passo do histograma inválido: %q", fields[1])
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil || count < 1 || count > MaxBounds {
+			return nil, fmt.Errorf("quantidade de limites do histograma deve estar entre 1 e %d", MaxBounds)
+		}
+
+		if kind == "linear" {
+			bounds = Linear(start, step, count)
+		} else {
+			bounds = Exponential(start, step, count)
+		}
+	case "lista":
+		fields := strings.Split(params, ",")
+		if len(fields) > MaxBounds {
+			return nil, fmt.Errorf("quantidade de limites do histograma deve estar entre 1 e %d", MaxBounds)
+		}
+		for _, field := range fields {
+			bound, err := decimal.Parse(strings.TrimSpace(field))
+			if err != nil {
+				return nil, fmt.Errorf("limite do histograma inválido: %q", field)
+			}
+			bounds = append(bounds, bound)
+		}
+	default:
+		return nil, fmt.Errorf("histograma deve ser linear, exponencial ou lista: %q", raw)
+	}
+
+	if err := Validate(bounds); err != nil {
+		return nil, err
+	}
+	return bounds, nil
+}
+
+// Validate verifica se os limites são não negativos e estritamente crescentes
+func Validate(bounds []decimal.Decimal) error {
+	for i, bound := range bounds {
+		if bound.Sign() < 0 || (i > 0 && bound.Cmp(bounds[i-1]) <= 0) {
+			return ErrInvalidBounds
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package sketch
+
+import (
+	"math"
+	"sort"
+)
+
+// minIndexableValue é o menor valor representado em um bin logarítmico.
+// Valores menores (incluindo zero) são contabilizados à parte.
+const minIndexableValue = 1e-9
+
+// DDSketch é um sketch de quantis com erro relativo garantido.
+// Os valores são distribuídos em bins logarítmicos, de modo que a memória
+// é limitada pela quantidade máxima de bins e dois sketches com a mesma
+// precisão podem ser combinados sem perda adicional.
+type DDSketch struct {
+	gamma     float64
+	logGamma  float64
+	maxBins   int
+	bins      map[int]uint64
+	zeroCount uint64
+	count     uint64
+}
+
+// NewDDSketch cria um sketch com a precisão relativa (ex.: 0.01 para 1%)
+// e o número máximo de bins informados
+func NewDDSketch(relativeAccuracy float64, maxBins int) *DDSketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &DDSketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+		bins:     make(map[int]uint64),
+	}
+}
+
+// Add inclui um valor no sketch. Valores negativos são tratados como zero.
+func (s *DDSketch) Add(value float64) {
+	s.count++
+	if value < minIndexableValue {
+		s.zeroCount++
+		return
+	}
+
+	index := int(math.Ceil(math.Log(value) / s.logGamma))
+	if _, ok := s.bins[index]; !ok && len(s.bins) >= s.maxBins {
+		s.bins[index] = 1
+		s.collapse()
+		return
+	}
+	s.bins[index]++
+}
+
+// Merge combina outro sketch a este. Ambos devem ter a mesma precisão.
+func (s *DDSketch) Merge(other *DDSketch) {
+	if other == nil || other.count == 0 {
+		return
+	}
+
+	s.count += other.count
+	s.zeroCount += other.zeroCount
+	for index, count := range other.bins {
+		s.bins[index] += count
+	}
+
+	if len(s.bins) > s.maxBins {
+		s.collapse()
+	}
+}
+
+// Count retorna a quantidade de valores incluídos no sketch
+func (s *DDSketch) Count() uint64 {
+	return s.count
+}
+
+// Quantile retorna uma estimativa do quantil q (entre 0 e 1).
+// Um sketch vazio retorna zero.
+func (s *DDSketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	q = math.Max(0, math.Min(1, q))
+
+	rank := uint64(q * float64(s.count-1))
+	if rank < s.zeroCount {
+		return 0
+	}
+
+	cumulative := s.zeroCount
+	indexes := s.sortedIndexes()
+	for _, index := range indexes {
+		cumulative += s.bins[index]
+		if cumulative > rank {
+			return s.value(index)
+		}
+	}
+
+	return s.value(indexes[len(indexes)-1])
+}
+
+// value retorna o valor representativo de um bin
+func (s *DDSketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// sortedIndexes retorna os índices dos bins em ordem crescente
+func (s *DDSketch) sortedIndexes() []int {
+	indexes := make([]int, 0, len(s.bins))
+	for index := range s.bins {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// collapse agrupa os bins de menor valor até respeitar o limite de bins,
+// preservando a precisão dos quantis mais altos
+func (s *DDSketch) collapse() {
+	indexes := s.sortedIndexes()
+	excess := len(indexes) - s.maxBins
+	if excess <= 0 {
+		return
+	}
+
+	target := indexes[excess]
+	for _, index := range indexes[:excess] {
+		s.bins[target] += s.bins[index]
+		delete(s.bins, index)
+	}
+}
@@ -1,6 +1,12 @@
 package utils
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -112,6 +118,11 @@ func NewSlidingWindow(duration time.Duration, provider TimeProvider) *SlidingWin
 	}
 }
 
+// Duration retorna a duração da janela deslizante
+func (w *SlidingWindow) Duration() time.Duration {
+	return w.duration
+}
+
 // GetWindow retorna a janela de tempo atual
 func (w *SlidingWindow) GetWindow() TimeWindow {
 	now := w.provider.Now()
@@ -121,11 +132,56 @@ func (w *SlidingWindow) GetWindow() TimeWindow {
 	}
 }
 
+// Previous retorna a janela de mesma duração imediatamente anterior à atual
+func (w *SlidingWindow) Previous() TimeWindow {
+	current := w.GetWindow()
+	return TimeWindow{
+		Start: current.Start.Add(-w.duration),
+		End:   current.Start,
+	}
+}
+
 // IsInWindow verifica se um timestamp está dentro da janela atual
 func (w *SlidingWindow) IsInWindow(t time.Time) bool {
 	return w.GetWindow().Contains(t)
 }
 
+// Granularidades dos períodos de calendário
+const (
+	GranularityMinute = "minuto"
+	GranularityHour   = "hora"
+	GranularityDay    = "dia"
+)
+
+// ErrInvalidGranularity indica que a granularidade não é minuto, hora nem dia
+var ErrInvalidGranularity = errors.New("granularidade deve ser minuto, hora ou dia")
+
+// CalendarPeriod retorna o período de calendário de t na granularidade
+// informada, alinhado ao fuso horário loc. O período inclui Start e exclui
+// End, de modo que períodos consecutivos não se sobrepõem.
+//
+// Minutos e horas são calculados a partir do instante, e não da hora local:
+// no fim do horário de verão a mesma hora local ocorre duas vezes, e cada
+// ocorrência é um período distinto.
+func CalendarPeriod(t time.Time, granularity string, loc *time.Location) (TimeWindow, error) {
+	t = t.In(loc)
+	intoMinute := time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	switch granularity {
+	case GranularityMinute:
+		start := t.Add(-intoMinute)
+		return NewTimeWindow(start, start.Add(time.Minute)), nil
+	case GranularityHour:
+		start := t.Add(-time.Duration(t.Minute())*time.Minute - intoMinute)
+		return NewTimeWindow(start, start.Add(time.Hour)), nil
+	case GranularityDay:
+		// Dias podem ter 23 ou 25 horas nas mudanças de horário de verão
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return NewTimeWindow(start, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)), nil
+	default:
+		return TimeWindow{}, ErrInvalidGranularity
+	}
+}
+
 // FormatISO formata um time.Time no padrão ISO 8601
 func FormatISO(t time.Time) string {
 	return t.Format(time.RFC3339)
@@ -135,3 +191,55 @@ func FormatISO(t time.Time) string {
 func ParseISO(s string) (time.Time, error) {
 	return time.Parse(time.RFC3339, s)
 }
+
+// isoDurationPattern reconhece durações ISO 8601 com dias, horas, minutos e segundos
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:[.,]\d+)?)S)?)?$`)
+
+// ParseDuration faz o parse de uma duração no formato do Go (ex.: "300s", "5m")
+// ou no formato ISO 8601 (ex.: "PT5M", "P1DT2H")
+func ParseDuration(s string) (time.Duration, error) {
+	iso := strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(iso, "P") {
+		return time.ParseDuration(strings.TrimSpace(s))
+	}
+
+	// "P" e "PT" sem componentes são sintaticamente inválidos
+	matches := isoDurationPattern.FindStringSubmatch(iso)
+	if matches == nil || iso == "P" || strings.HasSuffix(iso, "T") {
+		return 0, fmt.Errorf("duração ISO 8601 inválida: %q", s)
+	}
+
+	// Cada componente é verificado contra o que ainda cabe em um
+	// time.Duration antes de ser multiplicado e somado
+	overflow := fmt.Errorf("duração ISO 8601 excede o limite suportado: %q", s)
+
+	units := []time.Duration{24 * time.Hour, time.Hour, time.Minute}
+	var total time.Duration
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(matches[i+1], 10, 64)
+		if err != nil {
+			return 0, overflow
+		}
+		if n > (math.MaxInt64-int64(total))/int64(unit) {
+			return 0, overflow
+		}
+		total += time.Duration(n) * unit
+	}
+
+	if matches[4] != "" {
+		seconds, err := strconv.ParseFloat(strings.Replace(matches[4], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("duração ISO 8601 inválida: %q", s)
+		}
+		nanos := seconds * float64(time.Second)
+		if nanos >= float64(math.MaxInt64-int64(total)) {
+			return 0, overflow
+		}
+		total += time.Duration(nanos)
+	}
+
+	return total, nil
+}
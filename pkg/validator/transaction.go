@@ -1,63 +1,224 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"api-itau/internal/models"
+	"api-itau/pkg/currency"
+	"api-itau/pkg/decimal"
+)
+
+// Erros retornados pelo validador. Podem ser identificados com errors.Is
+// mesmo quando agrupados por Validate ou acompanhados de contexto. As regras
+// também verificadas pelo modelo (valor negativo e data futura) retornam os
+// erros de models.
+var (
+	ErrValueRequired     = errors.New("campo 'valor' é obrigatório")
+	ErrTimestampRequired = errors.New("campo 'dataHora' é obrigatório")
+	ErrValueTooLarge     = errors.New("valor excede o limite máximo permitido")
+	ErrTimestampTooOld   = errors.New("data da transação é muito antiga")
+	ErrInvalidTimestamp  = errors.New("formato de data inválido")
+	ErrDescriptionLength = errors.New("descrição excede o tamanho máximo permitido")
+	ErrDescriptionChars  = errors.New("descrição contém caracteres não permitidos")
+	ErrTooManyLabels     = errors.New("quantidade de rótulos excede o limite")
+	ErrInvalidLabelKey   = errors.New("nome de rótulo inválido")
+	ErrInvalidLabelValue = errors.New("valor de rótulo inválido")
 )
 
+const (
+	// MaxDescriptionLength é a quantidade máxima de caracteres da descrição
+	MaxDescriptionLength = 255
+	// MaxLabels é a quantidade máxima de rótulos de uma transação
+	MaxLabels = 10
+	// MaxLabelKeyLength é a quantidade máxima de caracteres do nome de um rótulo
+	MaxLabelKeyLength = 32
+	// MaxLabelValueLength é a quantidade máxima de caracteres do valor de um rótulo
+	MaxLabelValueLength = 64
+)
+
+// reservedLabelKeys são os nomes que não podem ser usados como rótulo, pois
+// identificam campos da transação no agrupamento das estatísticas
+var reservedLabelKeys = map[string]bool{
+	"moeda": true,
+	"tipo":  true,
+}
+
 // TransactionValidator encapsula a lógica de validação de transações
 type TransactionValidator struct {
-	maxValue float64
+	maxValue decimal.Decimal
+	maxAge   time.Duration
 }
 
-// NewTransactionValidator cria uma nova instância do validador
-func NewTransactionValidator(maxValue float64) *TransactionValidator {
+// NewTransactionValidator cria uma nova instância do validador. maxValue é o
+// maior valor aceito e maxAge a idade máxima da data da transação.
+func NewTransactionValidator(maxValue decimal.Decimal, maxAge time.Duration) *TransactionValidator {
 	return &TransactionValidator{
 		maxValue: maxValue,
+		maxAge:   maxAge,
+	}
+}
+
+// Validate verifica as regras dos campos obrigatórios de uma transação. Campos
+// nil são tratados como ausentes. O erro retornado agrupa todas as violações encontradas com
+// errors.Join, ou é nil quando a transação é válida.
+func (v *TransactionValidator) Validate(value *decimal.Decimal, timestamp *time.Time) error {
+	errs := []error{v.ValidateJSON(value != nil, timestamp != nil)}
+
+	if value != nil {
+		errs = append(errs, v.ValidateValue(*value))
+	}
+
+	if timestamp != nil {
+		errs = append(errs, v.ValidateTimestamp(*timestamp))
 	}
+
+	return errors.Join(errs...)
 }
 
 // ValidateValue verifica se o valor da transação é válido
-func (v *TransactionValidator) ValidateValue(value float64) error {
-	if value < 0 {
-		return fmt.Errorf("valor não pode ser negativo")
+func (v *TransactionValidator) ValidateValue(value decimal.Decimal) error {
+	if value.Sign() < 0 {
+		return models.ErrNegativeValue
 	}
 
-	if value > v.maxValue {
-		return fmt.Errorf("valor excede o limite máximo permitido de %.2f", v.maxValue)
+	if value.Cmp(v.maxValue) > 0 {
+		return fmt.Errorf("%w de %s", ErrValueTooLarge, v.maxValue)
 	}
 
 	return nil
 }
 
+// ValidateCurrency verifica se a moeda é um código ISO 4217. Uma moeda vazia
+// é válida e corresponde à moeda padrão.
+func (v *TransactionValidator) ValidateCurrency(code string) error {
+	if code == "" {
+		return nil
+	}
+
+	_, err := currency.Normalize(code)
+	return err
+}
+
+// ValidateDescription verifica o tamanho da descrição e se ela é um texto
+// UTF-8 sem caracteres de controle (como quebras de linha)
+func (v *TransactionValidator) ValidateDescription(description string) error {
+	if !utf8.ValidString(description) || strings.IndexFunc(description, unicode.IsControl) >= 0 {
+		return ErrDescriptionChars
+	}
+
+	if utf8.RuneCountInString(description) > MaxDescriptionLength {
+		return fmt.Errorf("%w de %d caracteres", ErrDescriptionLength, MaxDescriptionLength)
+	}
+
+	return nil
+}
+
+// ValidateLabels verifica a quantidade de rótulos, o nome e o valor de cada
+// um, agrupando as violações com errors.Join. Os nomes devem satisfazer
+// IsLabelKey, e os valores devem ter entre 1 e MaxLabelValueLength
+// caracteres, sem caracteres de controle e sem iniciar com "_", prefixo
+// reservado aos grupos gerados pelas estatísticas.
+func (v *TransactionValidator) ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("%w de %d", ErrTooManyLabels, MaxLabels)
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		if !IsLabelKey(key) {
+			errs = append(errs, &LabelError{Key: key, Err: ErrInvalidLabelKey})
+			continue
+		}
+
+		value := labels[key]
+		if value == "" || strings.HasPrefix(value, "_") || !utf8.ValidString(value) ||
+			utf8.RuneCountInString(value) > MaxLabelValueLength || strings.IndexFunc(value, unicode.IsControl) >= 0 {
+			errs = append(errs, &LabelError{Key: key, Err: ErrInvalidLabelValue})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LabelError identifica o rótulo que violou uma regra. Err é
+// ErrInvalidLabelKey ou ErrInvalidLabelValue.
+type LabelError struct {
+	Key string
+	Err error
+}
+
+func (e *LabelError) Error() string {
+	return fmt.Sprintf("%v: %q", e.Err, e.Key)
+}
+
+func (e *LabelError) Unwrap() error {
+	return e.Err
+}
+
+// IsLabelKey indica se o nome pode ser usado como rótulo: de 1 a
+// MaxLabelKeyLength letras minúsculas, dígitos ou "_", iniciando com letra,
+// e diferente dos campos da transação (moeda e tipo)
+func IsLabelKey(key string) bool {
+	if key == "" || len(key) > MaxLabelKeyLength || reservedLabelKeys[key] {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+		case i > 0 && (c >= '0' && c <= '9' || c == '_'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
 // ValidateTimestamp verifica se o timestamp da transação é válido
 func (v *TransactionValidator) ValidateTimestamp(timestamp time.Time) error {
 	now := time.Now()
 
 	// Verifica se a data está no futuro
 	if timestamp.After(now) {
-		return fmt.Errorf("data da transação não pode estar no futuro")
+		return models.ErrFutureTimestamp
 	}
 
-	// Verifica se a data é muito antiga (mais de 5 anos)
-	if timestamp.Before(now.AddDate(-5, 0, 0)) {
-		return fmt.Errorf("data da transação é muito antiga")
+	// Verifica se a data é mais antiga que a idade máxima permitida
+	if timestamp.Before(now.Add(-v.maxAge)) {
+		return ErrTimestampTooOld
 	}
 
 	return nil
 }
 
-// ValidateJSON verifica se os campos obrigatórios estão presentes
+// ValidateJSON verifica se os campos obrigatórios estão presentes, agrupando
+// a ausência de cada campo em um único erro
 func (v *TransactionValidator) ValidateJSON(hasValue, hasTimestamp bool) error {
+	var errs []error
+
 	if !hasValue {
-		return fmt.Errorf("campo 'valor' é obrigatório")
+		errs = append(errs, ErrValueRequired)
 	}
 
 	if !hasTimestamp {
-		return fmt.Errorf("campo 'dataHora' é obrigatório")
+		errs = append(errs, ErrTimestampRequired)
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // IsValidISOTimestamp verifica se a string está no formato ISO 8601
@@ -96,5 +257,5 @@ func ParseTimestamp(timestamp string) (time.Time, error) {
 		}
 	}
 
-	return time.Time{}, fmt.Errorf("formato de data inválido: %w", firstErr)
+	return time.Time{}, fmt.Errorf("%w: %w", ErrInvalidTimestamp, firstErr)
 }
@@ -13,6 +13,7 @@ import (
 	"api-itau/handlers"
 	"api-itau/internal/models"
 	"api-itau/internal/services"
+	"api-itau/pkg/decimal"
 	"api-itau/pkg/utils"
 )
 
@@ -25,7 +26,7 @@ func (l *mockLogger) Error(msg string, keyvals ...interface{}) {}
 // setupTimeProvider configura um provedor de tempo mockado para testes
 func setupTimeProvider() (*utils.MockTimeProvider, *config.Config) {
 	cfg := &config.Config{
-		Stats: config.StatsConfig{WindowSeconds: 60},
+		Stats: config.StatsConfig{WindowSeconds: 60, Scale: 2, Rounding: "half_even", TopMax: 100},
 	}
 	mockTime := utils.NewMockTimeProvider(time.Now())
 	utils.SetTimeProvider(mockTime)
@@ -38,6 +39,24 @@ func floatEquals(a, b float64) bool {
 	return math.Abs(a-b) < epsilon
 }
 
+// decodeStatistics extrai as estatísticas do envelope APIResponse
+func decodeStatistics(t *testing.T, body *bytes.Buffer) handlers.StatisticsResponse {
+	t.Helper()
+
+	var envelope struct {
+		Success bool                        `json:"success"`
+		Data    handlers.StatisticsResponse `json:"data"`
+	}
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		t.Fatalf("erro ao decodificar resposta: %v", err)
+	}
+	if !envelope.Success {
+		t.Fatalf("resposta sem sucesso: %s", body.String())
+	}
+
+	return envelope.Data
+}
+
 // TestTransactionEndpoints testa os endpoints de transação
 func TestTransactionEndpoints(t *testing.T) {
 	mockTime, cfg := setupTimeProvider()
@@ -123,9 +142,9 @@ func TestStatisticsEndpoint(t *testing.T) {
 
 	// Adiciona algumas transações
 	transactions := []models.Transaction{
-		{Value: 100.00, Timestamp: baseTime.Add(-30 * time.Second)},
-		{Value: 50.00, Timestamp: baseTime.Add(-45 * time.Second)},
-		{Value: 25.00, Timestamp: baseTime.Add(-15 * time.Second)},
+		{Value: decimal.MustParse("100.00"), Timestamp: baseTime.Add(-30 * time.Second)},
+		{Value: decimal.MustParse("50.00"), Timestamp: baseTime.Add(-45 * time.Second)},
+		{Value: decimal.MustParse("25.00"), Timestamp: baseTime.Add(-15 * time.Second)},
 	}
 
 	for _, tx := range transactions {
@@ -144,19 +163,15 @@ func TestStatisticsEndpoint(t *testing.T) {
 				status, http.StatusOK)
 		}
 
-		var response handlers.StatisticsResponse
-		err := json.NewDecoder(rr.Body).Decode(&response)
-		if err != nil {
-			t.Fatalf("erro ao decodificar resposta: %v", err)
-		}
+		response := decodeStatistics(t, rr.Body)
 
 		// Verifica os valores esperados
 		expectedStats := handlers.StatisticsResponse{
 			Count: 3,
-			Sum:   175.00,
-			Avg:   58.33,
-			Min:   25.00,
-			Max:   100.00,
+			Sum:   decimal.MustParse("175.00"),
+			Avg:   decimal.MustParse("58.33"),
+			Min:   decimal.MustParse("25.00"),
+			Max:   decimal.MustParse("100.00"),
 		}
 
 		if response.Count != expectedStats.Count {
@@ -164,22 +179,22 @@ func TestStatisticsEndpoint(t *testing.T) {
 				response.Count, expectedStats.Count)
 		}
 
-		if response.Sum != expectedStats.Sum {
+		if response.Sum.Cmp(expectedStats.Sum) != 0 {
 			t.Errorf("sum incorreto: obtido %v esperado %v",
 				response.Sum, expectedStats.Sum)
 		}
 
-		if !floatEquals(response.Avg, expectedStats.Avg) {
+		if response.Avg.Cmp(expectedStats.Avg) != 0 {
 			t.Errorf("avg incorreto: obtido %v esperado %v",
 				response.Avg, expectedStats.Avg)
 		}
 
-		if response.Min != expectedStats.Min {
+		if response.Min.Cmp(expectedStats.Min) != 0 {
 			t.Errorf("min incorreto: obtido %v esperado %v",
 				response.Min, expectedStats.Min)
 		}
 
-		if response.Max != expectedStats.Max {
+		if response.Max.Cmp(expectedStats.Max) != 0 {
 			t.Errorf("max incorreto: obtido %v esperado %v",
 				response.Max, expectedStats.Max)
 		}
@@ -196,7 +211,7 @@ func TestStatisticsEndpoint(t *testing.T) {
 
 		// Adiciona uma nova transação com o tempo atual
 		novaTransacao := models.Transaction{
-			Value:     1.00,
+			Value:     decimal.NewFromInt(1),
 			Timestamp: mockTime.Now(),
 		}
 		statsService.AddTransaction(novaTransacao)
@@ -206,8 +221,7 @@ func TestStatisticsEndpoint(t *testing.T) {
 
 		handler.ServeHTTP(rr, req)
 
-		var response handlers.StatisticsResponse
-		json.NewDecoder(rr.Body).Decode(&response)
+		response := decodeStatistics(t, rr.Body)
 
 		// Deve ter apenas a nova transação
 		if response.Count != 1 {
@@ -215,8 +229,8 @@ func TestStatisticsEndpoint(t *testing.T) {
 		}
 
 		// Verifica se os valores estão corretos
-		if response.Sum != 1.00 {
-			t.Errorf("soma incorreta: obtido %v esperado %v", response.Sum, 1.00)
+		if response.Sum.Cmp(decimal.NewFromInt(1)) != 0 {
+			t.Errorf("soma incorreta: obtido %v esperado %v", response.Sum, 1)
 		}
 	})
 }
@@ -241,9 +255,10 @@ func TestConcurrency(t *testing.T) {
 		go func(i int) {
 			// Alterna entre POST e GET
 			if i%2 == 0 {
+				// Mantém todas as transações dentro da janela de 60 segundos
 				body := map[string]interface{}{
 					"valor":    float64(i) + 0.99,
-					"dataHora": baseTime.Add(-time.Duration(i) * time.Second).Format(time.RFC3339),
+					"dataHora": baseTime.Add(-time.Duration(i%60) * time.Second).Format(time.RFC3339),
 				}
 				bodyBytes, _ := json.Marshal(body)
 				req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBuffer(bodyBytes))
@@ -268,8 +283,7 @@ func TestConcurrency(t *testing.T) {
 	rr := httptest.NewRecorder()
 	statisticsHandler.ServeHTTP(rr, req)
 
-	var response handlers.StatisticsResponse
-	json.NewDecoder(rr.Body).Decode(&response)
+	response := decodeStatistics(t, rr.Body)
 
 	if response.Count != numRequests/2 {
 		t.Errorf("número incorreto de transações: obtido %v esperado %v",
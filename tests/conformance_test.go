@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/internal/services"
+)
+
+// conformanceStep é uma requisição da sequência de conformidade e a resposta
+// esperada byte a byte. {id} é substituído pelo id da última transação criada.
+type conformanceStep struct {
+	name   string
+	method string
+	path   string
+	body   string
+	status int
+	// response vazio indica que a resposta não deve ter corpo
+	response string
+}
+
+// TestResponseProfiles verifica byte a byte as respostas de cada perfil
+func TestResponseProfiles(t *testing.T) {
+	mockTime, _ := setupTimeProvider()
+	timestamp := mockTime.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+
+	postValid := `{"valor": 10, "dataHora": "` + timestamp + `"}`
+	postOther := `{"valor": 20.5, "dataHora": "` + timestamp + `"}`
+	postNegative := `{"valor": -1, "dataHora": "` + timestamp + `"}`
+
+	profiles := []struct {
+		profile handlers.ResponseProfile
+		steps   []conformanceStep
+	}{
+		{handlers.ProfileSpec, []conformanceStep{
+			{"Estatísticas vazias", http.MethodGet, "/estatistica", "", http.StatusOK,
+				`{"count":0,"sum":0,"avg":0,"min":0,"max":0}` + "\n"},
+			{"Transação criada", http.MethodPost, "/transacao", postValid, http.StatusCreated, ""},
+			{"Segunda transação criada", http.MethodPost, "/transacao", postOther, http.StatusCreated, ""},
+			{"Transação inválida", http.MethodPost, "/transacao", postNegative, http.StatusUnprocessableEntity, ""},
+			{"Campos ausentes", http.MethodPost, "/transacao", `{}`, http.StatusUnprocessableEntity, ""},
+			{"JSON inválido", http.MethodPost, "/transacao", `{`, http.StatusBadRequest, ""},
+			{"Estatísticas", http.MethodGet, "/estatistica", "", http.StatusOK,
+				`{"count":2,"sum":30.5,"avg":15.25,"min":10,"max":20.5}` + "\n"},
+			{"Transações removidas", http.MethodDelete, "/transacao", "", http.StatusOK, ""},
+			{"Estatísticas após remoção", http.MethodGet, "/estatistica", "", http.StatusOK,
+				`{"count":0,"sum":0,"avg":0,"min":0,"max":0}` + "\n"},
+		}},
+		{handlers.ProfileEnveloped, []conformanceStep{
+			{"Estatísticas vazias", http.MethodGet, "/estatistica", "", http.StatusOK,
+				`{"success":true,"data":{"count":0,"sum":0,"avg":0,"min":0,"max":0}}` + "\n"},
+			{"Transação criada", http.MethodPost, "/transacao", postValid, http.StatusCreated,
+				`{"success":true,"data":{"id":"{id}","valor":10,"moeda":"BRL","dataHora":"` + timestamp + `"}}` + "\n"},
+			{"Transação inválida", http.MethodPost, "/transacao", postNegative, http.StatusUnprocessableEntity,
+				`{"success":false,"error":{"code":"invalid_transaction","message":"Transação inválida",` +
+					`"details":[{"campo":"valor","regra":"nao_negativo","mensagem":"valor não pode ser negativo","valorRejeitado":-1}]}}` + "\n"},
+			{"JSON inválido", http.MethodPost, "/transacao", `{`, http.StatusBadRequest,
+				`{"success":false,"error":{"code":"invalid_json","message":"JSON inválido"}}` + "\n"},
+			{"Estatísticas", http.MethodGet, "/estatistica", "", http.StatusOK,
+				`{"success":true,"data":{"count":1,"sum":10,"avg":10,"min":10,"max":10}}` + "\n"},
+			{"Transações removidas", http.MethodDelete, "/transacao", "", http.StatusOK,
+				`{"success":true,"data":{"message":"Todas as transações foram deletadas com sucesso"}}` + "\n"},
+		}},
+	}
+
+	for _, p := range profiles {
+		t.Run(string(p.profile), func(t *testing.T) {
+			_, cfg := setupTimeProvider()
+			log := &mockLogger{}
+			statsService := services.NewStatisticsService(cfg, log)
+			transactionService := services.NewTransactionService(statsService, log)
+
+			mux := http.NewServeMux()
+			responder := handlers.NewResponder(p.profile, handlers.ErrorFormatJSON)
+			mux.Handle("/transacao", handlers.NewTransactionHandler(transactionService, log,
+				handlers.WithResponder(responder)))
+			mux.Handle("/estatistica", handlers.NewStatisticsHandler(statsService, log,
+				handlers.WithStatisticsResponder(responder)))
+
+			lastID := ""
+			for _, step := range p.steps {
+				req := httptest.NewRequest(step.method, step.path, bytes.NewBufferString(step.body))
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, req)
+
+				if location := rr.Header().Get("Location"); location != "" {
+					lastID = strings.TrimPrefix(location, "/transacao/")
+				}
+
+				if rr.Code != step.status {
+					t.Errorf("%s: status code errado: obtido %v esperado %v", step.name, rr.Code, step.status)
+				}
+
+				expected := strings.ReplaceAll(step.response, "{id}", lastID)
+				if got := rr.Body.String(); got != expected {
+					t.Errorf("%s: corpo incorreto:\nobtido   %q\nesperado %q", step.name, got, expected)
+				}
+
+				contentType := rr.Header().Get("Content-Type")
+				if step.response == "" && contentType != "" {
+					t.Errorf("%s: resposta sem corpo não deveria ter Content-Type: %q", step.name, contentType)
+				}
+				if step.response != "" && contentType != "application/json" {
+					t.Errorf("%s: Content-Type incorreto: %q", step.name, contentType)
+				}
+			}
+		})
+	}
+}
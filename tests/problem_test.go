@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"api-itau/handlers"
+	"api-itau/internal/middleware"
+	"api-itau/internal/services"
+)
+
+// TestProblemDetails testa as respostas de erro no formato RFC 7807
+func TestProblemDetails(t *testing.T) {
+	_, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := middleware.RequestIDMiddleware(log)(
+		handlers.NewTransactionHandler(transactionService, log))
+	problemHandler := middleware.RequestIDMiddleware(log)(
+		handlers.NewTransactionHandler(transactionService, log, handlers.WithResponder(
+			handlers.NewResponder(handlers.ProfileEnveloped, handlers.ErrorFormatProblem))))
+
+	post := func(handler http.Handler, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(`{"valor": -1}`))
+		req.Header.Set("X-Request-ID", "req-123")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assertProblem := func(t *testing.T, rr *httptest.ResponseRecorder) {
+		t.Helper()
+
+		if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Fatalf("Content-Type incorreto: %q", ct)
+		}
+
+		var p struct {
+			Type     string                    `json:"type"`
+			Title    string                    `json:"title"`
+			Status   int                       `json:"status"`
+			Detail   string                    `json:"detail"`
+			Instance string                    `json:"instance"`
+			Code     string                    `json:"code"`
+			Details  []handlers.FieldViolation `json:"details"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+			t.Fatalf("erro ao decodificar problema: %v", err)
+		}
+
+		if p.Status != http.StatusUnprocessableEntity || p.Title != "Unprocessable Entity" ||
+			p.Type != "urn:api-itau:problema:invalid_transaction" || p.Code != "invalid_transaction" ||
+			p.Detail == "" || p.Instance != "req-123" || len(p.Details) != 2 {
+			t.Errorf("problema incorreto: %s", rr.Body.String())
+		}
+	}
+
+	t.Run("Negociado pelo Accept", func(t *testing.T) {
+		assertProblem(t, post(handler, "application/json;q=0.5, application/problem+json"))
+	})
+
+	t.Run("Accept com q=0", func(t *testing.T) {
+		rr := post(handler, "application/problem+json;q=0")
+		if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type incorreto: %q", ct)
+		}
+	})
+
+	t.Run("Formato configurado", func(t *testing.T) {
+		assertProblem(t, post(problemHandler, ""))
+	})
+
+	t.Run("Pânico recuperado", func(t *testing.T) {
+		recovered := func(responder handlers.Responder, accept string) *httptest.ResponseRecorder {
+			panicking := middleware.RequestIDMiddleware(log)(middleware.RecoveryMiddleware(log, responder.InternalError)(
+				http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("falha") })))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Request-ID", "req-456")
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			rr := httptest.NewRecorder()
+			panicking.ServeHTTP(rr, req)
+			return rr
+		}
+
+		// O formato segue a configuração e o Accept, como nos demais erros
+		problemResponder := handlers.NewResponder(handlers.ProfileEnveloped, handlers.ErrorFormatProblem)
+		for _, rr := range []*httptest.ResponseRecorder{
+			recovered(problemResponder, ""),
+			recovered(handlers.Responder{}, "application/problem+json"),
+		} {
+			var p map[string]interface{}
+			json.Unmarshal(rr.Body.Bytes(), &p)
+			if rr.Code != http.StatusInternalServerError || rr.Header().Get("Content-Type") != "application/problem+json" ||
+				p["status"] != float64(http.StatusInternalServerError) || p["instance"] != "req-456" {
+				t.Errorf("resposta incorreta: %d %s", rr.Code, rr.Body.String())
+			}
+		}
+
+		rr := recovered(handlers.Responder{}, "")
+		want := `{"success":false,"error":{"code":"internal_error","message":"Erro interno do servidor"}}` + "\n"
+		if rr.Code != http.StatusInternalServerError || rr.Body.String() != want {
+			t.Errorf("resposta incorreta: %d %s", rr.Code, rr.Body.String())
+		}
+
+		rr = recovered(handlers.NewResponder(handlers.ProfileSpec, handlers.ErrorFormatJSON), "")
+		if rr.Code != http.StatusInternalServerError || rr.Body.Len() != 0 {
+			t.Errorf("resposta incorreta no perfil spec: %d %s", rr.Code, rr.Body.String())
+		}
+	})
+}
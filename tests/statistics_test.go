@@ -0,0 +1,1073 @@
+package tests
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/internal/models"
+	"api-itau/internal/services"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/histogram"
+	"api-itau/pkg/utils"
+)
+
+// TestStatisticsPercentiles testa as métricas de distribuição opcionais
+func TestStatisticsPercentiles(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	baseTime := mockTime.Now()
+	for i := 1; i <= 100; i++ {
+		statsService.AddTransaction(models.Transaction{
+			Value:     decimal.NewFromInt(int64(i)),
+			Timestamp: baseTime.Add(-time.Duration(i%50) * time.Second),
+		})
+	}
+
+	t.Run("Resposta padrão sem distribuição", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/estatistica", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		response := decodeStatistics(t, rr.Body)
+		if response.Percentiles != nil || response.StdDev != nil || response.Variance != nil {
+			t.Errorf("métricas de distribuição não deveriam estar presentes: %+v", response)
+		}
+	})
+
+	t.Run("Percentis solicitados", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/estatistica?percentiles=50,99", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		response := decodeStatistics(t, rr.Body)
+		expected := map[string]float64{"p50": 50, "p99": 99}
+		for key, want := range expected {
+			got, ok := response.Percentiles[key]
+			if !ok {
+				t.Fatalf("percentil %s ausente", key)
+			}
+			if math.Abs(got-want)/want > 0.02 {
+				t.Errorf("%s incorreto: obtido %v esperado %v", key, got, want)
+			}
+		}
+
+		// Variância populacional de 1..100
+		if response.Variance == nil || !floatEquals(*response.Variance, 833.25) {
+			t.Errorf("variância incorreta: %v", response.Variance)
+		}
+		if response.StdDev == nil || !floatEquals(*response.StdDev, math.Sqrt(833.25)) {
+			t.Errorf("desvio padrão incorreto: %v", response.StdDev)
+		}
+	})
+
+	t.Run("Percentis por grupo e por moeda", func(t *testing.T) {
+		statsService := services.NewStatisticsService(cfg, log)
+		handler := handlers.NewStatisticsHandler(statsService, log)
+		for i := 1; i <= 100; i++ {
+			tx := models.Transaction{Value: decimal.NewFromInt(int64(i)), Timestamp: baseTime, Currency: "BRL", Type: models.TypeCredit}
+			if i > 50 {
+				tx.Currency, tx.Type = "USD", models.TypeDebit
+			}
+			statsService.AddTransaction(tx)
+		}
+
+		tests := []struct {
+			query    string
+			expected map[string]float64
+		}{
+			{"?percentiles=50&agrupar=tipo", map[string]float64{models.TypeCredit: 25, models.TypeDebit: 75}},
+			{"?percentiles=50&agrupar=moeda", map[string]float64{"BRL": 25, "USD": 75}},
+			{"?percentiles=50&moeda=USD", map[string]float64{"": 75}},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			response := decodeStatistics(t, rr.Body)
+			for name, want := range tt.expected {
+				stats := &response
+				if name != "" {
+					stats = response.Groups[name]
+				}
+				if stats == nil || stats.Count != 50 {
+					t.Fatalf("%s: grupo %q incorreto: %+v", tt.query, name, stats)
+				}
+				if got := stats.Percentiles["p50"]; math.Abs(got-want)/want > 0.02 {
+					t.Errorf("%s: p50 do grupo %q incorreto: obtido %v esperado %v", tt.query, name, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("Percentil inválido", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/estatistica?percentiles=0,101", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestStatisticsWindow testa a seleção da janela no momento da consulta
+func TestStatisticsWindow(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.RetentionSeconds = 300
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	baseTime := mockTime.Now()
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: baseTime.Add(-10 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(20), Timestamp: baseTime.Add(-90 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(30), Timestamp: baseTime.Add(-200 * time.Second)})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+		expectedCode   string
+	}{
+		{name: "Janela padrão", query: "", expectedStatus: http.StatusOK, expectedCount: 1},
+		{name: "Janela em segundos", query: "?janela=120s", expectedStatus: http.StatusOK, expectedCount: 2},
+		{name: "Janela ISO 8601", query: "?janela=PT5M", expectedStatus: http.StatusOK, expectedCount: 3},
+		{name: "Janela acima da retenção", query: "?janela=PT10M", expectedStatus: http.StatusBadRequest},
+		{name: "Janela fracionada", query: "?janela=1500ms", expectedStatus: http.StatusBadRequest},
+		{name: "Janela inválida", query: "?janela=abc", expectedStatus: http.StatusBadRequest},
+		{name: "Dias além do limite", query: "?janela=P999999999999D", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_window"},
+		{name: "Horas além do limite", query: "?janela=PT9999999999999H", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_window"},
+		{name: "Soma além do limite", query: "?janela=P106751DT23H47M17S", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_window"},
+		{name: "Minutos que estouram para 52s", query: "?janela=PT600479950316067M", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_window"},
+		{name: "Segundos além do limite", query: "?janela=PT99999999999S", expectedStatus: http.StatusBadRequest, expectedCode: "invalid_window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				var envelope struct {
+					Error struct {
+						Code string `json:"code"`
+					} `json:"error"`
+				}
+				if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+					t.Fatalf("erro ao decodificar resposta: %v", err)
+				}
+				if tt.expectedCode != "" && envelope.Error.Code != tt.expectedCode {
+					t.Errorf("código de erro incorreto: obtido %v esperado %v", envelope.Error.Code, tt.expectedCode)
+				}
+				return
+			}
+
+			response := decodeStatistics(t, rr.Body)
+			if response.Count != tt.expectedCount {
+				t.Errorf("count incorreto: obtido %v esperado %v", response.Count, tt.expectedCount)
+			}
+		})
+	}
+}
+
+// TestStatisticsSeries testa a série temporal de estatísticas
+func TestStatisticsSeries(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.RetentionSeconds = 300
+	log := &mockLogger{}
+
+	// Alinha o relógio a um múltiplo de 10 segundos para tornar os pontos previsíveis
+	baseTime := time.Unix(mockTime.Now().Unix()/10*10, 0)
+	mockTime.Set(baseTime)
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: baseTime.Add(-25 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(30), Timestamp: baseTime.Add(-21 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(5), Timestamp: baseTime.Add(-5 * time.Second)})
+
+	t.Run("Série alinhada ao passo", func(t *testing.T) {
+		url := "/estatistica/serie?passo=10s&inicio=" + baseTime.Add(-30*time.Second).Format(time.RFC3339) +
+			"&fim=" + baseTime.Format(time.RFC3339)
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleSeries(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		var envelope struct {
+			Data []handlers.SeriesPoint `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatalf("erro ao decodificar resposta: %v", err)
+		}
+
+		expected := []handlers.StatisticsResponse{
+			{Count: 2, Sum: decimal.NewFromInt(40), Avg: decimal.NewFromInt(20), Min: decimal.NewFromInt(10), Max: decimal.NewFromInt(30)},
+			{},
+			{Count: 1, Sum: decimal.NewFromInt(5), Avg: decimal.NewFromInt(5), Min: decimal.NewFromInt(5), Max: decimal.NewFromInt(5)},
+		}
+		if len(envelope.Data) != len(expected) {
+			t.Fatalf("quantidade de pontos incorreta: obtido %v esperado %v", len(envelope.Data), len(expected))
+		}
+		for i, point := range envelope.Data {
+			if !point.Start.Equal(baseTime.Add(time.Duration(i-3) * 10 * time.Second)) {
+				t.Errorf("ponto %d com início incorreto: %v", i, point.Start)
+			}
+			if point.StatisticsResponse.Count != expected[i].Count || point.Sum.Cmp(expected[i].Sum) != 0 ||
+				point.Min.Cmp(expected[i].Min) != 0 || point.Max.Cmp(expected[i].Max) != 0 {
+				t.Errorf("ponto %d incorreto: obtido %+v esperado %+v", i, point.StatisticsResponse, expected[i])
+			}
+		}
+	})
+
+	t.Run("Intervalo padrão pelo relógio do serviço", func(t *testing.T) {
+		// O relógio global adiantado não deve alterar o intervalo padrão
+		utils.SetTimeProvider(utils.NewMockTimeProvider(baseTime.Add(time.Hour)))
+		defer utils.SetTimeProvider(mockTime)
+
+		req := httptest.NewRequest(http.MethodGet, "/estatistica/serie?passo=10s", nil)
+		rr := httptest.NewRecorder()
+		handler.HandleSeries(rr, req)
+
+		var envelope struct {
+			Data []handlers.SeriesPoint `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&envelope)
+		if len(envelope.Data) == 0 || !envelope.Data[len(envelope.Data)-1].End.Equal(baseTime) {
+			t.Fatalf("série incorreta: %d %+v", rr.Code, envelope.Data)
+		}
+
+		count := 0
+		for _, point := range envelope.Data {
+			count += point.Count
+		}
+		if count != 3 {
+			t.Errorf("quantidade de transações incorreta: obtido %v esperado 3", count)
+		}
+	})
+
+	t.Run("Parâmetros fora dos limites", func(t *testing.T) {
+		for _, query := range []string{
+			"inicio=" + baseTime.Add(-time.Hour).Format(time.RFC3339),
+			"fim=9999-12-31T00:00:00Z&passo=P36500D",
+			"fim=" + baseTime.Add(time.Second).Format(time.RFC3339),
+			"passo=301s",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica/serie?"+query, nil)
+			rr := httptest.NewRecorder()
+			handler.HandleSeries(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", query, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("Passo longo percorre apenas a retenção", func(t *testing.T) {
+		// Cada ponto de 100 dias percorre apenas os segundos retidos
+		window := utils.NewTimeWindow(baseTime.Add(-time.Second), baseTime.Add(240*time.Hour))
+		points, err := statsService.GetSeries(window, 100*24*time.Hour)
+		if err != nil {
+			t.Fatalf("erro ao obter série: %v", err)
+		}
+		count := 0
+		for _, point := range points {
+			count += point.Count
+		}
+		if count != 3 {
+			t.Errorf("quantidade de transações incorreta: obtido %v esperado 3", count)
+		}
+	})
+}
+
+// TestStatisticsDecimalPrecision testa a soma exata e o arredondamento da média
+func TestStatisticsDecimalPrecision(t *testing.T) {
+	tests := []struct {
+		name        string
+		rounding    string
+		values      []string
+		expectedSum string
+		expectedAvg string
+	}{
+		{name: "Soma sem erro de ponto flutuante", rounding: "half_even", values: []string{"0.1", "0.2"}, expectedSum: "0.3", expectedAvg: "0.15"},
+		{name: "Arredondamento bancário", rounding: "half_even", values: []string{"0.01", "0.04"}, expectedSum: "0.05", expectedAvg: "0.02"},
+		{name: "Arredondamento half up", rounding: "half_up", values: []string{"0.01", "0.04"}, expectedSum: "0.05", expectedAvg: "0.03"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTime, cfg := setupTimeProvider()
+			cfg.Stats.Rounding = tt.rounding
+			log := &mockLogger{}
+
+			statsService := services.NewStatisticsService(cfg, log)
+			handler := handlers.NewStatisticsHandler(statsService, log)
+
+			for _, v := range tt.values {
+				statsService.AddTransaction(models.Transaction{Value: decimal.MustParse(v), Timestamp: mockTime.Now()})
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/estatistica", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			var envelope struct {
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+			if got := string(envelope.Data["sum"]); got != tt.expectedSum {
+				t.Errorf("sum incorreto: obtido %s esperado %s", got, tt.expectedSum)
+			}
+			if got := string(envelope.Data["avg"]); got != tt.expectedAvg {
+				t.Errorf("avg incorreto: obtido %s esperado %s", got, tt.expectedAvg)
+			}
+		})
+	}
+}
+
+// TestStatisticsCurrency testa as estatísticas por moeda e o total consolidado
+func TestStatisticsCurrency(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Currency.Base = "BRL"
+	cfg.Currency.Rates = map[string]decimal.Decimal{"USD": decimal.MustParse("5.10")}
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(2), Currency: "USD", Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(4), Currency: "USD", Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(1), Currency: "EUR", Timestamp: now})
+
+	get := func(query string) handlers.StatisticsResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/estatistica"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+		return decodeStatistics(t, rr.Body)
+	}
+
+	t.Run("Todas as moedas", func(t *testing.T) {
+		if response := get(""); response.Count != 4 || response.Groups != nil {
+			t.Errorf("estatísticas incorretas: %+v", response)
+		}
+	})
+
+	t.Run("Filtro por moeda", func(t *testing.T) {
+		response := get("?moeda=usd")
+		if response.Currency != "USD" || response.Count != 2 || response.Sum.Cmp(decimal.NewFromInt(6)) != 0 {
+			t.Errorf("estatísticas incorretas: %+v", response)
+		}
+	})
+
+	t.Run("Agrupado por moeda", func(t *testing.T) {
+		response := get("?agrupar=moeda")
+		if len(response.Groups) != 3 || response.Groups["USD"].Count != 2 || response.Groups["BRL"].Count != 1 {
+			t.Fatalf("grupos incorretos: %+v", response.Groups)
+		}
+
+		// 10 BRL + 6 USD × 5,10; EUR não possui cotação
+		consolidated := response.Consolidated
+		if consolidated == nil || consolidated.Currency != "BRL" || consolidated.Count != 3 ||
+			consolidated.Sum.Cmp(decimal.MustParse("40.6")) != 0 ||
+			len(consolidated.Unconverted) != 1 || consolidated.Unconverted[0] != "EUR" {
+			t.Errorf("total consolidado incorreto: %+v", consolidated)
+		}
+	})
+
+	t.Run("Parâmetros inválidos", func(t *testing.T) {
+		for _, query := range []string{"?moeda=REAL", "?moeda=XYZ", "?agrupar=Dia"} {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", query, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// TestStatisticsByType testa o agrupamento por tipo e o saldo
+func TestStatisticsByType(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	statsService.AddTransaction(models.Transaction{Value: decimal.MustParse("100.50"), Type: models.TypeCredit, Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.MustParse("30.25"), Type: models.TypeDebit, Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.MustParse("20"), Type: models.TypeDebit, Currency: "USD", Timestamp: now})
+	statsService.AddTransaction(models.Transaction{Value: decimal.MustParse("5"), Timestamp: now})
+
+	tests := []struct {
+		name            string
+		query           string
+		expectedGroups  map[string]int
+		expectedBalance string
+	}{
+		{"Todas as moedas", "?agrupar=tipo", map[string]int{"credito": 1, "debito": 2, "sem_tipo": 1}, "50.25"},
+		{"Apenas BRL", "?agrupar=tipo&moeda=BRL", map[string]int{"credito": 1, "debito": 1, "sem_tipo": 1}, "70.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			response := decodeStatistics(t, rr.Body)
+			if len(response.Groups) != len(tt.expectedGroups) {
+				t.Fatalf("grupos incorretos: %+v", response.Groups)
+			}
+			for name, count := range tt.expectedGroups {
+				if g, ok := response.Groups[name]; !ok || g.Count != count {
+					t.Errorf("grupo %s incorreto: %+v", name, g)
+				}
+			}
+			if response.Balance == nil || response.Balance.Cmp(decimal.MustParse(tt.expectedBalance)) != 0 {
+				t.Errorf("saldo incorreto: obtido %v esperado %s", response.Balance, tt.expectedBalance)
+			}
+		})
+	}
+}
+
+// TestStatisticsByLabel testa o agrupamento por rótulo, a cardinalidade
+// máxima e a expiração dos valores com a retenção
+func TestStatisticsByLabel(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.LabelMaxNames = 1
+	cfg.Stats.LabelMaxValues = 2
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	for _, channel := range []string{"app", "web", "app", "loja", "totem"} {
+		statsService.AddTransaction(models.Transaction{
+			Value:     decimal.NewFromInt(10),
+			Timestamp: now,
+			Labels:    map[string]string{"canal": channel},
+		})
+	}
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: now})
+
+	req := httptest.NewRequest(http.MethodGet, "/estatistica?agrupar=canal", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	response := decodeStatistics(t, rr.Body)
+	if response.Count != 6 {
+		t.Errorf("count incorreto: obtido %v esperado %v", response.Count, 6)
+	}
+
+	// Os valores acima da cardinalidade máxima são agregados em _outros, e
+	// transações sem o rótulo não pertencem a nenhum grupo
+	expected := map[string]int{"app": 2, "web": 1, handlers.OtherGroup: 2}
+	if len(response.Groups) != len(expected) {
+		t.Fatalf("grupos incorretos: %+v", response.Groups)
+	}
+	for name, count := range expected {
+		if g, ok := response.Groups[name]; !ok || g.Count != count {
+			t.Errorf("grupo %s incorreto: %+v", name, g)
+		}
+	}
+
+	groups := func(query string) map[string]*handlers.StatisticsResponse {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/estatistica?"+query, nil))
+		return decodeStatistics(t, rr.Body).Groups
+	}
+
+	t.Run("Rótulos além do limite não são agrupados", func(t *testing.T) {
+		statsService.AddTransaction(models.Transaction{
+			Value:     decimal.NewFromInt(10),
+			Timestamp: now,
+			Labels:    map[string]string{"loja": "0042"},
+		})
+		if got := groups("agrupar=loja"); len(got) != 0 {
+			t.Errorf("grupos incorretos: %+v", got)
+		}
+	})
+
+	t.Run("Valores expiram com a retenção", func(t *testing.T) {
+		later := now.Add(2 * time.Minute)
+		mockTime.Set(later)
+		statsService.AddTransaction(models.Transaction{
+			Value:     decimal.NewFromInt(10),
+			Timestamp: later,
+			Labels:    map[string]string{"canal": "pix"},
+		})
+		if got := groups("agrupar=canal"); len(got) != 1 || got["pix"] == nil || got["pix"].Count != 1 {
+			t.Errorf("grupos incorretos: %+v", got)
+		}
+	})
+}
+
+// TestStatisticsFilters testa os filtros por faixa de valor e por rótulo
+func TestStatisticsFilters(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	transactions := []models.Transaction{
+		{Value: decimal.NewFromInt(5), Timestamp: now},
+		{Value: decimal.NewFromInt(10000), Timestamp: now.Add(-time.Second)},
+		{Value: decimal.NewFromInt(15000), Timestamp: now, Labels: map[string]string{"canal": "app"}},
+		{Value: decimal.NewFromInt(20000), Timestamp: now, Labels: map[string]string{"canal": "web"}},
+	}
+	for _, tx := range transactions {
+		statsService.AddTransaction(tx)
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCount int
+		expectedSum   string
+	}{
+		{"Valor mínimo", "?valorMin=10000", 3, "45000"},
+		{"Faixa de valor", "?valorMin=10&valorMax=15000", 2, "25000"},
+		{"Rótulo", "?rotulo=canal:app", 1, "15000"},
+		{"Valor e rótulo", "?valorMin=16000&rotulo=canal:app", 0, "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+tt.query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			response := decodeStatistics(t, rr.Body)
+			if response.Count != tt.expectedCount || response.Sum.Cmp(decimal.MustParse(tt.expectedSum)) != 0 {
+				t.Errorf("estatísticas incorretas: %+v", response)
+			}
+			if response.Filters == nil {
+				t.Errorf("filtros aplicados ausentes na resposta")
+			}
+		})
+	}
+
+	t.Run("Sem filtros", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/estatistica", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if response := decodeStatistics(t, rr.Body); response.Count != 4 || response.Filters != nil {
+			t.Errorf("estatísticas incorretas: %+v", response)
+		}
+	})
+
+	t.Run("Filtros inválidos", func(t *testing.T) {
+		for _, query := range []string{"?valorMin=abc", "?valorMin=10&valorMax=5", "?rotulo=canal", "?rotulo=canal:app&rotulo=canal:web"} {
+			req := httptest.NewRequest(http.MethodGet, "/estatistica"+query, nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", query, rr.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("Janela com transações descartadas", func(t *testing.T) {
+		mockTime, cfg := setupTimeProvider()
+		cfg.Stats.MaxTransactions = 3
+
+		statsService := services.NewStatisticsService(cfg, log)
+		handler := handlers.NewStatisticsHandler(statsService, log)
+		for _, tx := range transactions {
+			statsService.AddTransaction(tx)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/estatistica?valorMin=10000", nil)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		response := decodeStatistics(t, rr.Body)
+		if response.Count != 2 || response.Filters == nil || !response.Filters.Partial {
+			t.Fatalf("resultado parcial não sinalizado: %+v", response)
+		}
+		if coverage := response.Filters.Coverage; coverage == nil || !coverage.Start.Equal(mockTime.Now().Truncate(time.Second)) {
+			t.Errorf("cobertura incorreta: %+v", coverage)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/estatistica?valorMin=10000&janela=PT1S", nil)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if response := decodeStatistics(t, rr.Body); response.Filters == nil || response.Filters.Partial || response.Filters.Coverage != nil {
+			t.Errorf("janela sem transações descartadas sinalizada como parcial: %+v", response.Filters)
+		}
+	})
+}
+
+// TestStatisticsTop testa o ranking das maiores transações da janela
+func TestStatisticsTop(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.TopMax = 3
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	for i, value := range []int64{50, 10, 70, 30, 70, 20} {
+		statsService.AddTransaction(models.Transaction{
+			ID:        string(rune('a' + i)),
+			Value:     decimal.NewFromInt(value),
+			Timestamp: now.Add(-time.Duration(i) * time.Second),
+		})
+	}
+	statsService.AddTransaction(models.Transaction{
+		ID:        "antiga",
+		Value:     decimal.NewFromInt(1000),
+		Timestamp: now.Add(-2 * time.Minute),
+	})
+
+	top := func(t *testing.T, query string) (int, []handlers.TopTransaction) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/estatistica/top"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleTop(rr, req)
+
+		var envelope struct {
+			Data []handlers.TopTransaction `json:"data"`
+		}
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+		}
+		return rr.Code, envelope.Data
+	}
+
+	t.Run("Maiores valores da janela", func(t *testing.T) {
+		code, transactions := top(t, "?n=3")
+		if code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", code, http.StatusOK)
+		}
+
+		// Em caso de empate, a transação mais antiga vem primeiro
+		expected := []string{"e", "c", "a"}
+		if len(transactions) != len(expected) {
+			t.Fatalf("quantidade incorreta: obtido %v esperado %v", len(transactions), len(expected))
+		}
+		for i, id := range expected {
+			if transactions[i].ID != id {
+				t.Errorf("posição %d incorreta: obtido %s esperado %s", i, transactions[i].ID, id)
+			}
+		}
+	})
+
+	t.Run("Transações fora da janela", func(t *testing.T) {
+		mockTime.Set(now.Add(3 * time.Second))
+		defer mockTime.Set(now)
+
+		// A janela de 6s já não inclui a transação "e", a mais antiga de valor 70
+		_, transactions := top(t, "?n=3&janela=6s")
+		if len(transactions) != 3 || transactions[0].ID != "c" || transactions[1].ID != "a" {
+			t.Errorf("ranking incorreto: %+v", transactions)
+		}
+	})
+
+	t.Run("Quantidade inválida", func(t *testing.T) {
+		for _, query := range []string{"?n=0", "?n=4", "?n=abc"} {
+			if code, _ := top(t, query); code != http.StatusBadRequest {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", query, code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// TestStatisticsHistogram testa as contagens por faixa de valor da janela
+func TestStatisticsHistogram(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	bounds, err := histogram.ParseBounds("lista:10,100")
+	if err != nil {
+		t.Fatalf("erro ao interpretar limites: %v", err)
+	}
+	cfg.Stats.HistogramBounds = bounds
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	for _, value := range []int64{5, 10, 50, 100} {
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(value), Timestamp: now})
+	}
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(500), Timestamp: now.Add(-50 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(1000), Timestamp: now.Add(-2 * time.Minute)})
+
+	counts := func(t *testing.T) []int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/estatistica/histograma", nil)
+		rr := httptest.NewRecorder()
+		handler.HandleHistogram(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		var envelope struct {
+			Data handlers.HistogramResponse `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatalf("erro ao decodificar resposta: %v", err)
+		}
+
+		result := make([]int, len(envelope.Data.Buckets))
+		for i, bucket := range envelope.Data.Buckets {
+			result[i] = bucket.Count
+		}
+		return result
+	}
+
+	t.Run("Faixas da janela", func(t *testing.T) {
+		if got := counts(t); !slices.Equal(got, []int{2, 2, 1}) {
+			t.Errorf("contagens incorretas: obtido %v esperado %v", got, []int{2, 2, 1})
+		}
+	})
+
+	t.Run("Transação saindo da janela", func(t *testing.T) {
+		mockTime.Set(now.Add(20 * time.Second))
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(20), Timestamp: now.Add(15 * time.Second)})
+
+		if got := counts(t); !slices.Equal(got, []int{2, 3, 0}) {
+			t.Errorf("contagens incorretas: obtido %v esperado %v", got, []int{2, 3, 0})
+		}
+	})
+
+	t.Run("Transação atrasada após o avanço da janela", func(t *testing.T) {
+		mockTime, cfg := setupTimeProvider()
+		cfg.Stats.HistogramBounds = bounds
+		cfg.Stats.RetentionSeconds = 300
+		statsService := services.NewStatisticsService(cfg, log)
+		handler = handlers.NewStatisticsHandler(statsService, log)
+
+		now := mockTime.Now()
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(5), Timestamp: now})
+
+		// A transação estava na janela do último avanço, mas não está mais
+		mockTime.Set(now.Add(30 * time.Second))
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(50), Timestamp: now.Add(-40 * time.Second)})
+
+		if got := counts(t); !slices.Equal(got, []int{1, 0, 0}) {
+			t.Errorf("contagens incorretas: obtido %v esperado %v", got, []int{1, 0, 0})
+		}
+	})
+
+	t.Run("Limites inválidos", func(t *testing.T) {
+		for _, raw := range []string{"lista:10,5", "linear:0,10", "exponencial:1,2,1000", "faixas:1,2"} {
+			if _, err := histogram.ParseBounds(raw); err == nil {
+				t.Errorf("%s: limites deveriam ser rejeitados", raw)
+			}
+		}
+	})
+}
+
+// TestStatisticsThroughput testa a taxa atual e as médias móveis de chegada
+func TestStatisticsThroughput(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	now := mockTime.Now()
+	for i := 0; i < 10; i++ {
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: now})
+	}
+
+	throughput := func(t *testing.T) handlers.ThroughputResponse {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/estatistica/taxa", nil)
+		rr := httptest.NewRecorder()
+		handler.HandleThroughput(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		var envelope struct {
+			Data handlers.ThroughputResponse `json:"data"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+			t.Fatalf("erro ao decodificar resposta: %v", err)
+		}
+		return envelope.Data
+	}
+
+	t.Run("Taxa atual da janela", func(t *testing.T) {
+		response := throughput(t)
+		if !floatEquals(response.TPS, 10.0/60) || response.ValuePerSecond.Cmp(decimal.MustParse("1.67")) != 0 {
+			t.Errorf("taxa incorreta: %+v", response)
+		}
+		// Nenhum intervalo foi encerrado, então as médias ainda não foram atualizadas
+		if response.TPSAverages.M1 != 0 {
+			t.Errorf("média móvel não deveria ter sido atualizada: %+v", response.TPSAverages)
+		}
+	})
+
+	t.Run("Médias móveis após um intervalo", func(t *testing.T) {
+		mockTime.Set(now.Add(5 * time.Second))
+
+		// 10 transações em 5 segundos resultam em 2 por segundo no intervalo
+		response := throughput(t)
+		expected := 2 * (1 - math.Exp(-5.0/60))
+		if math.Abs(response.TPSAverages.M1-expected) > 1e-9 {
+			t.Errorf("média de 1 minuto incorreta: obtido %v esperado %v", response.TPSAverages.M1, expected)
+		}
+		if math.Abs(response.ValueAverages.M1-10*expected) > 1e-9 {
+			t.Errorf("média de valor de 1 minuto incorreta: obtido %v esperado %v", response.ValueAverages.M1, 10*expected)
+		}
+		if !(response.TPSAverages.M1 > response.TPSAverages.M5 && response.TPSAverages.M5 > response.TPSAverages.M15) {
+			t.Errorf("médias mais longas deveriam reagir mais devagar: %+v", response.TPSAverages)
+		}
+	})
+
+	t.Run("Decaimento sem transações", func(t *testing.T) {
+		mockTime.Set(now.Add(65 * time.Second))
+
+		response := throughput(t)
+		expected := 2 * (1 - math.Exp(-5.0/60)) * math.Exp(-1)
+		if math.Abs(response.TPSAverages.M1-expected) > 1e-9 {
+			t.Errorf("média de 1 minuto incorreta: obtido %v esperado %v", response.TPSAverages.M1, expected)
+		}
+		if response.TPS != 0 {
+			t.Errorf("transações fora da janela não deveriam compor a taxa atual: %v", response.TPS)
+		}
+	})
+}
+
+// TestStatisticsComparison testa a comparação da janela atual com a anterior
+func TestStatisticsComparison(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	now := mockTime.Now()
+	mockTime.Set(now.Add(-65 * time.Second))
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	// A transação da janela anterior é recebida enquanto ainda estava na janela
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: now.Add(-70 * time.Second)})
+
+	mockTime.Set(now)
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: now.Add(-10 * time.Second)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(20), Timestamp: now.Add(-5 * time.Second)})
+
+	req := httptest.NewRequest(http.MethodGet, "/estatistica/comparativo", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleComparison(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+	}
+
+	var envelope struct {
+		Data handlers.ComparisonResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+		t.Fatalf("erro ao decodificar resposta: %v", err)
+	}
+	comparison := envelope.Data
+
+	if comparison.Current.Count != 2 || comparison.Previous.Count != 1 {
+		t.Fatalf("janelas incorretas: atual %+v anterior %+v", comparison.Current, comparison.Previous)
+	}
+
+	deltas := comparison.Deltas
+	if deltas.Count.Absolute.Cmp(decimal.NewFromInt(1)) != 0 || deltas.Count.Percent == nil || !floatEquals(*deltas.Count.Percent, 100) {
+		t.Errorf("variação de count incorreta: %+v", deltas.Count)
+	}
+	if deltas.Sum.Absolute.Cmp(decimal.NewFromInt(20)) != 0 || deltas.Sum.Percent == nil || !floatEquals(*deltas.Sum.Percent, 200) {
+		t.Errorf("variação de sum incorreta: %+v", deltas.Sum)
+	}
+	if deltas.Avg.Absolute.Cmp(decimal.NewFromInt(5)) != 0 || deltas.Avg.Percent == nil || !floatEquals(*deltas.Avg.Percent, 50) {
+		t.Errorf("variação de avg incorreta: %+v", deltas.Avg)
+	}
+
+	t.Run("Janela anterior vazia", func(t *testing.T) {
+		mockTime.Set(now.Add(-62 * time.Second))
+		defer mockTime.Set(now)
+
+		comparison, err := statsService.Compare()
+		if err != nil {
+			t.Fatalf("erro ao comparar janelas: %v", err)
+		}
+		if comparison.Previous.Count != 0 || comparison.Deltas.Count.Percent != nil {
+			t.Errorf("variação percentual deveria ser nula: %+v", comparison.Deltas.Count)
+		}
+	})
+}
+
+// TestStatisticsPeriods testa as agregações por período de calendário
+func TestStatisticsPeriods(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.PeriodTimezones = []string{"America/Sao_Paulo", "UTC"}
+	cfg.Stats.PeriodRetention = 48 * time.Hour
+	log := &mockLogger{}
+
+	// 14:30 UTC corresponde a 11:30 em São Paulo (UTC-3)
+	now := time.Date(2025, 3, 10, 14, 30, 0, 0, time.UTC)
+	mockTime.Set(now)
+
+	statsService := services.NewStatisticsService(cfg, log)
+	handler := handlers.NewStatisticsHandler(statsService, log)
+
+	for _, timestamp := range []time.Time{
+		time.Date(2025, 3, 10, 14, 10, 0, 0, time.UTC),
+		time.Date(2025, 3, 10, 13, 50, 0, 0, time.UTC),
+		// 23:00 do dia anterior em São Paulo, fora da retenção dos buckets
+		time.Date(2025, 3, 10, 2, 0, 0, 0, time.UTC),
+	} {
+		statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: timestamp})
+	}
+
+	periods := func(t *testing.T, query string) (int, []handlers.SeriesPoint) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/estatistica/periodo"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.HandlePeriods(rr, req)
+
+		var envelope struct {
+			Data []handlers.SeriesPoint `json:"data"`
+		}
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&envelope); err != nil {
+				t.Fatalf("erro ao decodificar resposta: %v", err)
+			}
+		}
+		return rr.Code, envelope.Data
+	}
+
+	t.Run("Dias no fuso de São Paulo", func(t *testing.T) {
+		_, points := periods(t, "?granularidade=dia&tz=America/Sao_Paulo")
+		if len(points) != 3 {
+			t.Fatalf("quantidade de períodos incorreta: obtido %v esperado 3", len(points))
+		}
+
+		today := points[2]
+		if !today.Start.Equal(time.Date(2025, 3, 10, 3, 0, 0, 0, time.UTC)) || today.Count != 2 ||
+			today.Sum.Cmp(decimal.NewFromInt(20)) != 0 {
+			t.Errorf("dia atual incorreto: %+v", today)
+		}
+		if points[1].Count != 1 {
+			t.Errorf("dia anterior incorreto: %+v", points[1])
+		}
+	})
+
+	t.Run("Dias em UTC", func(t *testing.T) {
+		_, points := periods(t, "?granularidade=dia&tz=UTC")
+		if len(points) == 0 || points[len(points)-1].Count != 3 {
+			t.Errorf("dia atual incorreto: %+v", points)
+		}
+	})
+
+	t.Run("Horas do dia atual", func(t *testing.T) {
+		_, points := periods(t, "?granularidade=hora")
+		if len(points) != 12 {
+			t.Fatalf("quantidade de períodos incorreta: obtido %v esperado 12", len(points))
+		}
+		if points[10].Count != 1 || points[11].Count != 1 || points[0].Count != 0 {
+			t.Errorf("horas incorretas: %+v", points)
+		}
+	})
+
+	t.Run("Intervalo padrão pelo relógio do serviço", func(t *testing.T) {
+		// O relógio global no dia seguinte não deve alterar o dia atual
+		utils.SetTimeProvider(utils.NewMockTimeProvider(now.Add(24 * time.Hour)))
+		defer utils.SetTimeProvider(mockTime)
+
+		_, points := periods(t, "?granularidade=hora&tz=UTC")
+		if len(points) != 15 || points[13].Count != 1 || points[14].Count != 1 {
+			t.Errorf("horas incorretas: %+v", points)
+		}
+	})
+
+	t.Run("Parâmetros inválidos", func(t *testing.T) {
+		for _, query := range []string{"?granularidade=semana", "?tz=Europe/Paris", "?granularidade=minuto&inicio=2025-03-01T00:00:00Z"} {
+			if code, _ := periods(t, query); code != http.StatusBadRequest {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", query, code, http.StatusBadRequest)
+			}
+		}
+	})
+}
+
+// TestStatisticsPeriodsDST testa as horas repetidas no fim do horário de verão
+func TestStatisticsPeriodsDST(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.PeriodTimezones = []string{"America/New_York"}
+	cfg.Stats.PeriodRetention = 48 * time.Hour
+	log := &mockLogger{}
+
+	// Em 2025-11-02 às 06:00 UTC os relógios de Nova York voltam de 02:00 EDT
+	// para 01:00 EST, de modo que 01:00 local ocorre às 05:00 e às 06:00 UTC
+	mockTime.Set(time.Date(2025, 11, 2, 8, 0, 0, 0, time.UTC))
+
+	statsService := services.NewStatisticsService(cfg, log)
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(10), Timestamp: time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC)})
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(20), Timestamp: time.Date(2025, 11, 2, 6, 30, 0, 0, time.UTC)})
+
+	points, err := statsService.GetPeriods(handlers.PeriodQuery{
+		Granularity: utils.GranularityHour,
+		Timezone:    "America/New_York",
+		Window: utils.NewTimeWindow(
+			time.Date(2025, 11, 2, 4, 0, 0, 0, time.UTC),
+			time.Date(2025, 11, 2, 8, 0, 0, 0, time.UTC),
+		),
+	})
+	if err != nil {
+		t.Fatalf("erro ao obter períodos: %v", err)
+	}
+
+	expected := []int{0, 1, 1, 0}
+	if len(points) != len(expected) {
+		t.Fatalf("quantidade de períodos incorreta: obtido %v esperado %v", len(points), len(expected))
+	}
+	for i, point := range points {
+		start := time.Date(2025, 11, 2, 4+i, 0, 0, 0, time.UTC)
+		if !point.Start.Equal(start) || point.End.Sub(point.Start) != time.Hour || point.Count != expected[i] {
+			t.Errorf("período %d incorreto: %v a %v com %d transações", i, point.Start, point.End, point.Count)
+		}
+	}
+}
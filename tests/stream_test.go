@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/internal/models"
+	"api-itau/internal/services"
+	"api-itau/pkg/decimal"
+)
+
+// readEvent lê o próximo evento SSE e decodifica as estatísticas
+func readEvent(t *testing.T, reader *bufio.Reader) handlers.StatisticsResponse {
+	t.Helper()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("erro ao ler evento: %v", err)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var stats handlers.StatisticsResponse
+			if err := json.Unmarshal([]byte(data), &stats); err != nil {
+				t.Fatalf("erro ao decodificar evento: %v", err)
+			}
+			return stats
+		}
+	}
+}
+
+// TestStatisticsStream testa o stream de estatísticas via Server-Sent Events
+func TestStatisticsStream(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	broadcaster := services.NewStatisticsBroadcaster(statsService, time.Hour, log)
+	broadcaster.Start()
+
+	server := httptest.NewServer(handlers.NewStatisticsStreamHandler(broadcaster, log))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("erro ao conectar ao stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("content-type incorreto: %q", contentType)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+
+	// O snapshot inicial é enviado na inscrição
+	if stats := readEvent(t, reader); stats.Count != 0 {
+		t.Errorf("snapshot inicial deveria estar vazio: %+v", stats)
+	}
+
+	// Uma nova transação gera um evento imediato, sem aguardar o intervalo
+	statsService.AddTransaction(models.Transaction{Value: decimal.NewFromInt(42), Timestamp: mockTime.Now()})
+	if stats := readEvent(t, reader); stats.Count != 1 || stats.Sum.Cmp(decimal.NewFromInt(42)) != 0 {
+		t.Errorf("evento incorreto após transação: %+v", stats)
+	}
+
+	// O encerramento do broadcaster finaliza o stream
+	broadcaster.Close()
+	done := make(chan struct{})
+	go func() {
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream não foi encerrado após o fechamento do broadcaster")
+	}
+}
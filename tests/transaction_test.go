@@ -0,0 +1,737 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/internal/idempotency"
+	"api-itau/internal/middleware"
+	"api-itau/internal/models"
+	"api-itau/internal/services"
+	"api-itau/pkg/decimal"
+	"api-itau/pkg/validator"
+)
+
+// uuidV7Pattern reconhece identificadores UUIDv7
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// TestTransactionLookup testa a identificação de transações e a consulta por id
+func TestTransactionLookup(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /transacao", handler)
+	mux.Handle("GET /transacao/{id}", handler)
+
+	body := `{"valor": 99.9, "dataHora": "` + mockTime.Now().Add(-5*time.Second).Format(time.RFC3339) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusCreated)
+	}
+
+	var created struct {
+		Data handlers.TransactionResponse `json:"data"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+		t.Fatalf("erro ao decodificar resposta: %v", err)
+	}
+
+	if !uuidV7Pattern.MatchString(created.Data.ID) {
+		t.Fatalf("id não é um UUIDv7: %q", created.Data.ID)
+	}
+
+	location := rr.Header().Get("Location")
+	if location != "/transacao/"+created.Data.ID {
+		t.Fatalf("header Location incorreto: %q", location)
+	}
+
+	t.Run("Consulta por id", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, location, nil))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		var found struct {
+			Data handlers.TransactionResponse `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&found)
+		if found.Data.ID != created.Data.ID || found.Data.Value.Cmp(decimal.MustParse("99.9")) != 0 {
+			t.Errorf("transação incorreta: %+v", found.Data)
+		}
+	})
+
+	t.Run("Id desconhecido", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/transacao/desconhecido", nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("Transação fora da retenção", func(t *testing.T) {
+		mockTime.Add(2 * time.Minute)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, location, nil))
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusNotFound)
+		}
+	})
+}
+
+// TestTransactionIDOrder testa que os ids usam o relógio do TimeProvider e
+// são crescentes mesmo quando gerados no mesmo milissegundo
+func TestTransactionIDOrder(t *testing.T) {
+	mockTime, _ := setupTimeProvider()
+	// Posterior aos ids já gerados, que nunca retrocedem
+	now := mockTime.Now().Add(24 * time.Hour).Truncate(time.Millisecond)
+	mockTime.Set(now)
+
+	previous := ""
+	for i := 0; i < 1000; i++ {
+		id, err := models.NewID()
+		if err != nil {
+			t.Fatalf("erro ao gerar id: %v", err)
+		}
+		if !uuidV7Pattern.MatchString(id) {
+			t.Fatalf("id não é um UUIDv7: %q", id)
+		}
+		if id <= previous {
+			t.Fatalf("ids fora de ordem: %s depois de %s", id, previous)
+		}
+		previous = id
+	}
+
+	// Os 48 primeiros bits são o timestamp do relógio mockado
+	prefix := fmt.Sprintf("%012x", now.UnixMilli())
+	if !strings.HasPrefix(previous, prefix[:8]+"-"+prefix[8:]+"-") {
+		t.Errorf("timestamp do id incorreto: %s", previous)
+	}
+}
+
+// TestTransactionStorageLimit testa o limite de transações armazenadas e o
+// header Location das transações que não são armazenadas
+func TestTransactionStorageLimit(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	cfg.Stats.MaxTransactions = 2
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /transacao", handler)
+	mux.Handle("GET /transacao/{id}", handler)
+
+	post := func(t *testing.T, age time.Duration) string {
+		t.Helper()
+		body := `{"valor": 10, "dataHora": "` + mockTime.Now().Add(-age).Format(time.RFC3339) + `"}`
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(body)))
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusCreated)
+		}
+		return rr.Header().Get("Location")
+	}
+
+	get := func(location string) int {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, location, nil))
+		return rr.Code
+	}
+
+	t.Run("Transações mais antigas descartadas", func(t *testing.T) {
+		locations := []string{post(t, 3*time.Second), post(t, 2*time.Second), post(t, time.Second)}
+
+		if code := get(locations[0]); code != http.StatusNotFound {
+			t.Errorf("transação descartada: status code errado: obtido %v esperado %v", code, http.StatusNotFound)
+		}
+		for _, location := range locations[1:] {
+			if code := get(location); code != http.StatusOK {
+				t.Errorf("%s: status code errado: obtido %v esperado %v", location, code, http.StatusOK)
+			}
+		}
+
+		// As transações descartadas continuam nas estatísticas
+		if stats, _ := statsService.GetStatistics(); stats.Count != 3 {
+			t.Errorf("count incorreto: obtido %v esperado 3", stats.Count)
+		}
+	})
+
+	t.Run("Transação fora da retenção sem Location", func(t *testing.T) {
+		if location := post(t, 5*time.Minute); location != "" {
+			t.Errorf("header Location não deveria ser informado: %q", location)
+		}
+	})
+}
+
+// TestIdempotencyKey testa o suporte ao header Idempotency-Key
+func TestIdempotencyKey(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	store := idempotency.NewStore(time.Minute, 100, mockTime)
+	handler := handlers.NewTransactionHandler(transactionService, log, handlers.WithIdempotency(store))
+	statsHandler := handlers.NewStatisticsHandler(statsService, log)
+
+	timestamp := mockTime.Now().Add(-time.Second).Format(time.RFC3339)
+	post := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(body))
+		req.Header.Set(handlers.IdempotencyKeyHeader, key)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	count := func() int {
+		rr := httptest.NewRecorder()
+		statsHandler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/estatistica", nil))
+		return decodeStatistics(t, rr.Body).Count
+	}
+
+	body := `{"valor": 10, "dataHora": "` + timestamp + `"}`
+	first := post("chave-1", body)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("status code errado: obtido %v esperado %v", first.Code, http.StatusCreated)
+	}
+
+	t.Run("Repetição retorna a resposta original", func(t *testing.T) {
+		repeated := post("chave-1", body)
+		if repeated.Code != http.StatusCreated {
+			t.Errorf("status code errado: obtido %v esperado %v", repeated.Code, http.StatusCreated)
+		}
+		if repeated.Body.String() != first.Body.String() {
+			t.Errorf("corpo diferente do original: %s", repeated.Body.String())
+		}
+		if repeated.Header().Get("Location") != first.Header().Get("Location") {
+			t.Errorf("Location diferente do original: %s", repeated.Header().Get("Location"))
+		}
+		if c := count(); c != 1 {
+			t.Errorf("transação contada mais de uma vez: %d", c)
+		}
+	})
+
+	t.Run("Chave reutilizada com outro corpo", func(t *testing.T) {
+		rr := post("chave-1", `{"valor": 20, "dataHora": "`+timestamp+`"}`)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("Chave expirada é processada novamente", func(t *testing.T) {
+		mockTime.Add(2 * time.Minute)
+		rr := post("chave-1", body)
+		if rr.Code != http.StatusCreated {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusCreated)
+		}
+		if rr.Header().Get("Location") == first.Header().Get("Location") {
+			t.Error("transação deveria ter sido criada novamente")
+		}
+	})
+
+	t.Run("Pânico libera a chave", func(t *testing.T) {
+		panicking := handlers.NewTransactionHandler(&panickingService{TransactionService: transactionService}, log,
+			handlers.WithIdempotency(store))
+		recovered := middleware.RecoveryMiddleware(log, nil)(panicking)
+
+		req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(body))
+		req.Header.Set(handlers.IdempotencyKeyHeader, "chave-2")
+		rr := httptest.NewRecorder()
+		recovered.ServeHTTP(rr, req)
+		if rr.Code != http.StatusInternalServerError {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusInternalServerError)
+		}
+
+		if rr := post("chave-2", body); rr.Code != http.StatusCreated {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusCreated)
+		}
+	})
+}
+
+// panickingService é um TransactionService cuja inclusão entra em pânico
+type panickingService struct {
+	handlers.TransactionService
+}
+
+func (s *panickingService) AddTransaction(models.Transaction) (bool, error) {
+	panic("falha ao adicionar transação")
+}
+
+// TestBatchEndpoint testa a inclusão de transações em lote
+func TestBatchEndpoint(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log, handlers.WithBatchLimit(3))
+	statsHandler := handlers.NewStatisticsHandler(statsService, log)
+
+	timestamp := mockTime.Now().Add(-time.Second).Format(time.RFC3339)
+	valid := `{"valor": 10, "dataHora": "` + timestamp + `"}`
+	negative := `{"valor": -1, "dataHora": "` + timestamp + `"}`
+
+	post := func(url, body string) (*httptest.ResponseRecorder, handlers.BatchResponse) {
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		handler.HandleBatch(rr, req)
+
+		var envelope struct {
+			Data handlers.BatchResponse `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &envelope)
+		return rr, envelope.Data
+	}
+	count := func() int {
+		rr := httptest.NewRecorder()
+		statsHandler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/estatistica", nil))
+		return decodeStatistics(t, rr.Body).Count
+	}
+
+	t.Run("Lote atômico com item inválido", func(t *testing.T) {
+		rr, response := post("/transacao/lote?atomic=true", "["+valid+","+negative+"]")
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+		if response.Accepted != 0 || len(response.Results) != 2 {
+			t.Errorf("resposta incorreta: %+v", response)
+		}
+		if c := count(); c != 0 {
+			t.Errorf("nenhuma transação deveria ter sido registrada: %d", c)
+		}
+	})
+
+	t.Run("Lote parcial", func(t *testing.T) {
+		rr, response := post("/transacao/lote", "["+valid+","+negative+",{\"valor\":\"x\"}]")
+		if rr.Code != http.StatusMultiStatus {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusMultiStatus)
+		}
+
+		expected := []struct {
+			status int
+			code   string
+		}{
+			{http.StatusCreated, ""},
+			{http.StatusUnprocessableEntity, "invalid_transaction"},
+			{http.StatusBadRequest, "invalid_json"},
+		}
+		for i, want := range expected {
+			got := response.Results[i]
+			if got.Index != i || got.Status != want.status || got.Code != want.code {
+				t.Errorf("resultado %d incorreto: %+v", i, got)
+			}
+		}
+		if response.Results[0].ID == "" {
+			t.Error("transação aceita deveria ter id")
+		}
+		if c := count(); c != 1 {
+			t.Errorf("count incorreto: obtido %v esperado 1", c)
+		}
+	})
+
+	t.Run("Lote acima do limite", func(t *testing.T) {
+		rr, _ := post("/transacao/lote", "["+valid+","+valid+","+valid+","+valid+"]")
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("Lote que não é um array", func(t *testing.T) {
+		rr, _ := post("/transacao/lote", valid)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+// TestStreamEndpoint testa a inclusão de transações via NDJSON
+func TestStreamEndpoint(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log, handlers.WithStreamLimits(64, 4))
+
+	timestamp := mockTime.Now().Add(-time.Second).Format(time.RFC3339)
+	valid := `{"valor": 10, "dataHora": "` + timestamp + `"}`
+
+	post := func(contentType, body string) (*httptest.ResponseRecorder, handlers.IngestResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/transacao/stream", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		handler.HandleStream(rr, req)
+
+		var envelope struct {
+			Data handlers.IngestResponse `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &envelope)
+		return rr, envelope.Data
+	}
+
+	t.Run("Stream com linhas inválidas", func(t *testing.T) {
+		body := valid + "\n\n{\"valor\": -1}\n" + `{"valor": 1, "x": "` + strings.Repeat("a", 80) + `"}` + "\n" + valid
+		rr, response := post("application/x-ndjson", body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+		if response.Lines != 4 || response.Accepted != 2 || response.Rejected != 2 {
+			t.Errorf("resumo incorreto: %+v", response)
+		}
+
+		expected := []handlers.IngestLineError{
+			{Line: 3, Code: "invalid_transaction"},
+			{Line: 4, Code: "line_too_long"},
+		}
+		for i, want := range expected {
+			if got := response.Errors[i]; got.Line != want.Line || got.Code != want.Code {
+				t.Errorf("erro %d incorreto: %+v", i, got)
+			}
+		}
+	})
+
+	t.Run("Linha com o tamanho máximo", func(t *testing.T) {
+		exact := valid + strings.Repeat(" ", 64-len(valid))
+		body := exact + "\n" + exact + "\r\n" + exact + " \n" + exact
+		rr, response := post("application/x-ndjson", body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+		if response.Accepted != 3 || response.Rejected != 1 || response.Errors[0].Line != 3 ||
+			response.Errors[0].Code != "line_too_long" {
+			t.Errorf("resumo incorreto: %+v", response)
+		}
+	})
+
+	t.Run("Linha com dados após o JSON", func(t *testing.T) {
+		handler := handlers.NewTransactionHandler(transactionService, log, handlers.WithStreamLimits(256, 4))
+		body := valid + " " + valid + "\n" + valid + " x\n" + valid + " \n"
+		req := httptest.NewRequest(http.MethodPost, "/transacao/stream", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		rr := httptest.NewRecorder()
+		handler.HandleStream(rr, req)
+
+		var envelope struct {
+			Data handlers.IngestResponse `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &envelope)
+		response := envelope.Data
+		if response.Lines != 3 || response.Accepted != 1 || response.Rejected != 2 {
+			t.Fatalf("resumo incorreto: %+v", response)
+		}
+		for i, line := range []int{1, 2} {
+			if got := response.Errors[i]; got.Line != line || got.Code != "invalid_json" {
+				t.Errorf("erro %d incorreto: %+v", i, got)
+			}
+		}
+	})
+
+	t.Run("Stream acima do limite de linhas", func(t *testing.T) {
+		body := strings.Repeat(valid+"\n", 5)
+		rr, response := post("application/x-ndjson", body)
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusRequestEntityTooLarge)
+		}
+		if response.Accepted != 4 {
+			t.Errorf("as linhas anteriores ao limite deveriam ter sido aceitas: %+v", response)
+		}
+	})
+
+	t.Run("Content-Type inválido", func(t *testing.T) {
+		rr, _ := post("application/json", valid)
+		if rr.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusUnsupportedMediaType)
+		}
+	})
+}
+
+// TestCSVImportExport testa a importação e a exportação de transações em CSV
+func TestCSVImportExport(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log)
+
+	first := mockTime.Now().Add(-2 * time.Second).UTC().Format(time.RFC3339)
+	second := mockTime.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+
+	importCSV := func(url, body string) (*httptest.ResponseRecorder, handlers.IngestResponse) {
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+		rr := httptest.NewRecorder()
+		handler.HandleImport(rr, req)
+
+		var envelope struct {
+			Data handlers.IngestResponse `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &envelope)
+		return rr, envelope.Data
+	}
+
+	t.Run("Importação pt-BR com cabeçalho", func(t *testing.T) {
+		body := "dataHora;valor\n" +
+			second + ";\"1.234,50\"\n" +
+			first + ";abc\n" +
+			"ontem;10\n" +
+			first + ";-1\n" +
+			first + ";0,5\n"
+		rr, response := importCSV("/transacao/importar?delimitador=%3B", body)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+		if response.Lines != 5 || response.Accepted != 2 || response.Rejected != 3 {
+			t.Fatalf("resumo incorreto: %+v", response)
+		}
+
+		expected := []handlers.IngestLineError{
+			{Line: 3, Code: "invalid_value"},
+			{Line: 4, Code: "invalid_timestamp"},
+			{Line: 5, Code: "invalid_transaction"},
+		}
+		for i, want := range expected {
+			if got := response.Errors[i]; got.Line != want.Line || got.Code != want.Code {
+				t.Errorf("erro %d incorreto: %+v", i, got)
+			}
+		}
+	})
+
+	t.Run("Exportação", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.HandleExport(rr, httptest.NewRequest(http.MethodGet, "/transacao/exportar?decimal=virgula", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		if len(lines) != 3 || lines[0] != "id,valor,dataHora,moeda,tipo,descricao,rotulos" {
+			t.Fatalf("CSV exportado incorreto: %q", rr.Body.String())
+		}
+
+		// As transações são exportadas em ordem cronológica
+		for i, want := range []string{`"0,5",` + first + ",BRL,,,", `"1234,5",` + second + ",BRL,,,"} {
+			if !strings.HasSuffix(lines[i+1], want) {
+				t.Errorf("linha %d incorreta: obtido %q esperado sufixo %q", i+1, lines[i+1], want)
+			}
+		}
+	})
+
+	t.Run("Exportação sem fórmulas", func(t *testing.T) {
+		statsService := services.NewStatisticsService(cfg, log)
+		transactionService := services.NewTransactionService(statsService, log)
+		handler := handlers.NewTransactionHandler(transactionService, log)
+
+		descriptions := []string{`=HYPERLINK("http://x","y")`, "+cmd|' /C calc'!A0", "-1+1", "@SUM(A1)", "\tTAB", "Compra"}
+		for _, description := range descriptions {
+			transactionService.AddTransaction(models.Transaction{
+				Value:       decimal.NewFromInt(1),
+				Timestamp:   mockTime.Now().Add(-time.Second),
+				Description: description,
+			})
+		}
+
+		rr := httptest.NewRecorder()
+		handler.HandleExport(rr, httptest.NewRequest(http.MethodGet, "/transacao/exportar", nil))
+		records, err := csv.NewReader(rr.Body).ReadAll()
+		if err != nil || len(records) != len(descriptions)+1 {
+			t.Fatalf("CSV exportado incorreto: %v %q", err, records)
+		}
+		for i, description := range descriptions {
+			want := "'" + description
+			if description == "Compra" {
+				want = description
+			}
+			if got := records[i+1][5]; got != want {
+				t.Errorf("descrição %d incorreta: obtido %q esperado %q", i, got, want)
+			}
+		}
+	})
+
+	t.Run("Importação sem cabeçalho", func(t *testing.T) {
+		rr, response := importCSV("/transacao/importar", "10.5,"+first+"\n\"20\",x\"y\n")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, http.StatusOK)
+		}
+		if response.Accepted != 1 || response.Rejected != 1 || response.Errors[0].Line != 2 {
+			t.Errorf("resumo incorreto: %+v", response)
+		}
+	})
+
+	t.Run("Delimitador inválido", func(t *testing.T) {
+		rr, _ := importCSV("/transacao/importar?delimitador=ab", "")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("status code errado: obtido %v esperado %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Rótulos na exportação e importação", func(t *testing.T) {
+		// Cada etapa usa um serviço vazio, para comparar apenas as transações importadas
+		roundTrip := func(body string) (handlers.IngestResponse, string) {
+			statsService := services.NewStatisticsService(cfg, log)
+			handler := handlers.NewTransactionHandler(services.NewTransactionService(statsService, log), log)
+
+			req := httptest.NewRequest(http.MethodPost, "/transacao/importar", bytes.NewBufferString(body))
+			req.Header.Set("Content-Type", "text/csv")
+			rr := httptest.NewRecorder()
+			handler.HandleImport(rr, req)
+
+			var envelope struct {
+				Data handlers.IngestResponse `json:"data"`
+			}
+			json.Unmarshal(rr.Body.Bytes(), &envelope)
+
+			rr = httptest.NewRecorder()
+			handler.HandleExport(rr, httptest.NewRequest(http.MethodGet, "/transacao/exportar", nil))
+			return envelope.Data, rr.Body.String()
+		}
+
+		response, exported := roundTrip("valor,dataHora,rotulos\n" +
+			"10," + first + ",loja=00%2642&canal=app\n" +
+			"20," + second + ",\n" +
+			"30," + second + ",canal=app&canal=web\n")
+		if response.Accepted != 2 || response.Rejected != 1 || response.Errors[0].Code != "invalid_labels" {
+			t.Fatalf("resumo incorreto: %+v", response)
+		}
+
+		lines := strings.Split(strings.TrimSpace(exported), "\n")
+		if len(lines) != 3 || !strings.HasSuffix(lines[1], ",canal=app&loja=00%2642") || !strings.HasSuffix(lines[2], ",BRL,,,") {
+			t.Fatalf("CSV exportado incorreto: %q", exported)
+		}
+
+		// O CSV exportado é importado novamente sem perda dos rótulos
+		response, reexported := roundTrip(exported)
+		if response.Accepted != 2 || response.Rejected != 0 {
+			t.Fatalf("resumo incorreto: %+v", response)
+		}
+		for i, line := range strings.Split(strings.TrimSpace(reexported), "\n")[1:] {
+			_, want, _ := strings.Cut(lines[i+1], ",")
+			if _, got, _ := strings.Cut(line, ","); got != want {
+				t.Errorf("linha %d incorreta: obtido %q esperado %q", i+1, got, want)
+			}
+		}
+	})
+}
+
+// TestTransactionValidation testa a detecção de campos ausentes e o retorno de
+// cada regra violada com o campo correspondente
+func TestTransactionValidation(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	handler := handlers.NewTransactionHandler(transactionService, log,
+		handlers.WithValidator(validator.NewTransactionValidator(decimal.NewFromInt(1000), time.Hour)))
+
+	recent := mockTime.Now().Add(-time.Second).Format(time.RFC3339)
+	old := mockTime.Now().Add(-2 * time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expected       []handlers.FieldViolation
+	}{
+		{"Valor zero", `{"valor": 0, "dataHora": "` + recent + `"}`, http.StatusCreated, nil},
+		{"Campos ausentes", `{}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "valor", Rule: "obrigatorio"},
+			{Field: "dataHora", Rule: "obrigatorio"},
+		}},
+		{"Campos nulos", `{"valor": null, "dataHora": null}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "valor", Rule: "obrigatorio"},
+			{Field: "dataHora", Rule: "obrigatorio"},
+		}},
+		{"Valor acima do limite", `{"valor": 1000.01, "dataHora": "` + recent + `"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "valor", Rule: "valor_maximo", Value: 1000.01},
+		}},
+		{"Valor negativo e data antiga", `{"valor": -1, "dataHora": "` + old + `"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "valor", Rule: "nao_negativo", Value: -1.0},
+			{Field: "dataHora", Rule: "idade_maxima", Value: old},
+		}},
+		{"Valor com tipo inválido", `{"valor": "10", "dataHora": "` + recent + `"}`, http.StatusBadRequest, []handlers.FieldViolation{
+			{Field: "valor", Rule: "tipo"},
+		}},
+		{"Data com formato inválido", `{"valor": 10, "dataHora": "ontem"}`, http.StatusBadRequest, []handlers.FieldViolation{
+			{Field: "dataHora", Rule: "formato"},
+		}},
+		{"Tipo e descrição", `{"valor": 10, "dataHora": "` + recent + `", "tipo": "Crédito", "descricao": "Salário"}`, http.StatusCreated, nil},
+		{"Tipo e descrição inválidos", `{"valor": 10, "dataHora": "` + recent + `", "tipo": "pix", "descricao": "linha\nquebrada"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "tipo", Rule: "enum", Value: "pix"},
+			{Field: "descricao", Rule: "caracteres", Value: "linha\nquebrada"},
+		}},
+		{"Descrição longa", `{"valor": 10, "dataHora": "` + recent + `", "descricao": "` + strings.Repeat("á", 256) + `"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "descricao", Rule: "tamanho_maximo", Value: strings.Repeat("á", validator.MaxDescriptionLength) + "…"},
+		}},
+		{"Rótulos inválidos", `{"valor": 10, "dataHora": "` + recent + `", "rotulos": {"Canal": "app", "loja": "_x", "x` + strings.Repeat("y", 40) + `": "web"}}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "rotulos", Rule: "nome", Value: map[string]interface{}{"Canal": "app"}},
+			{Field: "rotulos", Rule: "valor", Value: map[string]interface{}{"loja": "_x"}},
+			{Field: "rotulos", Rule: "nome", Value: map[string]interface{}{"x" + strings.Repeat("y", validator.MaxLabelKeyLength-1) + "…": "web"}},
+		}},
+		{"Rótulos em excesso", `{"valor": 10, "dataHora": "` + recent + `", "rotulos": {"a": "1", "b": "2", "c": "3", "d": "4", "e": "5", "f": "6", "g": "7", "h": "8", "i": "9", "j": "10", "k": "11"}}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "rotulos", Rule: "quantidade_maxima", Value: 11.0},
+		}},
+		{"Moeda inválida", `{"valor": 10, "dataHora": "` + recent + `", "moeda": "REAL"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "moeda", Rule: "formato", Value: "REAL"},
+		}},
+		{"Moeda inexistente", `{"valor": 10, "dataHora": "` + recent + `", "moeda": "XYZ"}`, http.StatusUnprocessableEntity, []handlers.FieldViolation{
+			{Field: "moeda", Rule: "formato", Value: "XYZ"},
+		}},
+		{"Moeda em minúsculas", `{"valor": 10, "dataHora": "` + recent + `", "moeda": "usd"}`, http.StatusCreated, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("status code errado: obtido %v esperado %v", rr.Code, tt.expectedStatus)
+			}
+			if tt.expected == nil {
+				return
+			}
+
+			var response handlers.APIResponse
+			json.Unmarshal(rr.Body.Bytes(), &response)
+			if response.Error == nil || len(response.Error.Details) != len(tt.expected) {
+				t.Fatalf("violações incorretas: obtido %+v esperado %+v", response.Error, tt.expected)
+			}
+
+			for i, want := range tt.expected {
+				got := response.Error.Details[i]
+				if got.Field != want.Field || got.Rule != want.Rule || got.Message == "" {
+					t.Errorf("violação %d incorreta: obtido %+v esperado %+v", i, got, want)
+				}
+				if want.Value != nil && !reflect.DeepEqual(got.Value, want.Value) {
+					t.Errorf("valor rejeitado %d incorreto: obtido %v esperado %v", i, got.Value, want.Value)
+				}
+			}
+		})
+	}
+}
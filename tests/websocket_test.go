@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"api-itau/handlers"
+	"api-itau/internal/services"
+)
+
+// wsTestClient é um cliente WebSocket mínimo para os testes
+type wsTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dialWebSocket realiza o handshake com o servidor de testes
+func dialWebSocket(t *testing.T, serverURL, path string) *wsTestClient {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(serverURL, "http://"))
+	if err != nil {
+		t.Fatalf("erro ao conectar: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	handshake := "GET " + path + " HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatalf("erro ao enviar handshake: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("erro ao ler resposta do handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status do handshake incorreto: %v", resp.StatusCode)
+	}
+	// Valor de referência da RFC 6455 para a chave acima
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Sec-WebSocket-Accept incorreto: %q", accept)
+	}
+
+	return &wsTestClient{conn: conn, reader: reader}
+}
+
+// send envia um frame de texto mascarado, como exigido para clientes
+func (c *wsTestClient) send(t *testing.T, payload []byte) {
+	t.Helper()
+
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x81, 0x80 | byte(len(payload))}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatalf("erro ao enviar frame: %v", err)
+	}
+}
+
+// receive lê o próximo frame de texto enviado pelo servidor
+func (c *wsTestClient) receive(t *testing.T) map[string]interface{} {
+	t.Helper()
+
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		t.Fatalf("erro ao ler frame: %v", err)
+	}
+
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		t.Fatalf("erro ao ler payload: %v", err)
+	}
+
+	var msg map[string]interface{}
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		t.Fatalf("erro ao decodificar mensagem %q: %v", payload, err)
+	}
+	return msg
+}
+
+// TestWebSocketFeed testa o feed de transações e estatísticas via WebSocket
+func TestWebSocketFeed(t *testing.T) {
+	mockTime, cfg := setupTimeProvider()
+	log := &mockLogger{}
+
+	statsService := services.NewStatisticsService(cfg, log)
+	transactionService := services.NewTransactionService(statsService, log)
+	broadcaster := services.NewStatisticsBroadcaster(statsService, time.Hour, log)
+	broadcaster.Start()
+	defer broadcaster.Close()
+
+	wsHandler := handlers.NewWebSocketHandler(broadcaster, log)
+	transactionService.OnTransaction(wsHandler.PublishTransaction)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, log)
+
+	server := httptest.NewServer(wsHandler)
+	defer server.Close()
+	defer wsHandler.Close()
+
+	t.Run("Handshake inválido", func(t *testing.T) {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatalf("erro na requisição: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("status code errado: obtido %v esperado %v", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Inscrição e recebimento de transações", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL, "/ws")
+		defer client.conn.Close()
+
+		client.send(t, []byte(`{"acao":"inscrever","topicos":["transacoes"]}`))
+		if msg := client.receive(t); msg["tipo"] != "inscricoes" {
+			t.Fatalf("confirmação de inscrição esperada: %v", msg)
+		}
+
+		body := `{"valor": 12.5, "dataHora": "` + mockTime.Now().Add(-time.Second).Format(time.RFC3339) + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/transacao", bytes.NewBufferString(body))
+		transactionHandler.ServeHTTP(httptest.NewRecorder(), req)
+
+		msg := client.receive(t)
+		if msg["topico"] != handlers.TopicTransactions {
+			t.Fatalf("evento de transação esperado: %v", msg)
+		}
+		if data, ok := msg["dados"].(map[string]interface{}); !ok || data["valor"] != 12.5 {
+			t.Errorf("dados da transação incorretos: %v", msg["dados"])
+		}
+	})
+
+	t.Run("Inscrição em estatísticas pela query string", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL, "/ws?topicos=estatisticas")
+		defer client.conn.Close()
+
+		// O snapshot inicial e a confirmação podem chegar em qualquer ordem
+		seen := map[string]bool{}
+		for i := 0; i < 2; i++ {
+			msg := client.receive(t)
+			seen[msg["tipo"].(string)] = true
+			if msg["tipo"] == "evento" && msg["topico"] != handlers.TopicStatistics {
+				t.Errorf("evento de estatísticas esperado: %v", msg)
+			}
+		}
+		if !seen["inscricoes"] || !seen["evento"] {
+			t.Errorf("confirmação e snapshot esperados: %v", seen)
+		}
+	})
+
+	t.Run("Tópico inválido", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL, "/ws")
+		defer client.conn.Close()
+
+		client.send(t, []byte(`{"acao":"inscrever","topicos":["outro"]}`))
+		if msg := client.receive(t); msg["tipo"] != "erro" {
+			t.Errorf("mensagem de erro esperada: %v", msg)
+		}
+	})
+}